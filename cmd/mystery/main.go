@@ -0,0 +1,86 @@
+// Command mystery provides authoring tools for mystery JSON files.
+//
+//	mystery validate [-static web/static] [file or dir ...]
+//	mystery schema
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tahcohcat/gofigure-web/internal/validator"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  mystery validate [-static web/static] [file or dir ...]   (default: data/mysteries)")
+	fmt.Fprintln(os.Stderr, "  mystery schema                                          print the mystery JSON Schema")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "validate":
+		os.Exit(validate(os.Args[2:]))
+	case "schema":
+		os.Stdout.Write(validator.Schema())
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func validate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	staticDir := fs.String("static", "web/static", "directory sprite paths are resolved against")
+	fs.Parse(args)
+
+	targets := fs.Args()
+	if len(targets) == 0 {
+		targets = []string{"data/mysteries"}
+	}
+
+	v, err := validator.New(*staticDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	var issues []validator.Issue
+	for _, target := range targets {
+		info, err := os.Stat(target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+
+		var found []validator.Issue
+		if info.IsDir() {
+			found, err = v.ValidateDir(target)
+		} else {
+			found, err = v.ValidateFile(target)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		issues = append(issues, found...)
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+
+	if len(issues) > 0 {
+		fmt.Fprintf(os.Stderr, "✗ %d issue(s) found\n", len(issues))
+		return 1
+	}
+
+	fmt.Fprintln(os.Stderr, "✓ all mysteries are valid")
+	return 0
+}
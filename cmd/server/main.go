@@ -14,7 +14,9 @@ import (
 	"github.com/tahcohcat/gofigure-web/internal/auth"
 	"github.com/tahcohcat/gofigure-web/internal/credits"
 	"github.com/tahcohcat/gofigure-web/internal/database"
+	"github.com/tahcohcat/gofigure-web/internal/game"
 	"github.com/tahcohcat/gofigure-web/internal/services"
+	"github.com/tahcohcat/gofigure-web/internal/validator"
 	"github.com/tahcohcat/gofigure-web/internal/websocket"
 
 	// Import SQLite driver
@@ -41,6 +43,8 @@ func setupViper() {
 	viper.SetDefault("auth.disabled", false)
 	viper.SetDefault("auth.login_password", "")
 	viper.SetDefault("database.url", "users.db")
+	viper.SetDefault("mysteries.dir", "data/mysteries")
+	viper.SetDefault("static.dir", "./web/static")
 
 	// Read environment variables
 	viper.SetEnvPrefix("GOFIGURE")
@@ -59,6 +63,23 @@ func main() {
 	}
 	defer db.Close()
 
+	// Index the available mysteries and pick up new files as they are added.
+	// Files that fail `mystery validate` are not offered to players.
+	staticDir := viper.GetString("static.dir")
+	mysteryValidator, err := validator.New(staticDir)
+	if err != nil {
+		log.Fatalf("Failed to load mystery schema: %v", err)
+	}
+
+	mysteries, err := game.NewMysteryRegistry(viper.GetString("mysteries.dir"), mysteryValidator)
+	if err != nil {
+		log.Fatalf("Failed to load mysteries: %v", err)
+	}
+	if err := mysteries.Watch(); err != nil {
+		log.Printf("Warning: mysteries will not reload on change: %v", err)
+	}
+	defer mysteries.Close()
+
 	// Initialize services
 	userService := services.NewUserService(db)
 
@@ -73,7 +94,7 @@ func main() {
 	r.HandleFunc("/register", auth.RegisterHandler).Methods("GET", "POST")
 	r.HandleFunc("/logout", auth.LogoutHandler).Methods("GET", "POST")
 	r.HandleFunc("/credits", credits.Handler)
-	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static/"))))
+	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir))))
 
 	// Authenticated routes
 	authRouter := r.PathPrefix("/").Subrouter()
@@ -85,7 +106,11 @@ func main() {
 
 	// API routes with user service integration
 	apiRouter := authRouter.PathPrefix("/api/v1").Subrouter()
-	gameHandler := api.RegisterRoutes(apiRouter, userService)
+	gameHandler := api.RegisterRoutes(apiRouter, userService, mysteries)
+	defer gameHandler.Close()
+
+	// Readiness probe, outside authentication so load balancers can reach it
+	r.HandleFunc("/ready", gameHandler.Ready).Methods("GET")
 
 	// TTS routes (requires game handler for mystery data access)
 	api.RegisterTTSRoutes(apiRouter, gameHandler)
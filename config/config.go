@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
@@ -8,13 +10,42 @@ type Config struct {
 	LLM    LLMConfig    `mapstructure:"llm"`
 	Ollama OllamaConfig `mapstructure:"ollama"`
 	OpenAI OpenAIConfig `mapstructure:"openai"`
+	Replay ReplayConfig `mapstructure:"replay"`
 	Tts    TtsConfig    `mapstructure:"tts"`
 	Sst    SstConfig    `mapstructure:"sst"`
+	Game   GameConfig   `mapstructure:"game"`
 }
 
 // LLM provider selection
 type LLMConfig struct {
-	Provider string `mapstructure:"provider"` // "ollama" or "openai"
+	Provider           string        `mapstructure:"provider"`             // "ollama", "openai", "replay" or "offline"
+	Providers          []string      `mapstructure:"providers"`            // providers to try in order; empty uses only provider
+	HealthCheckSeconds int           `mapstructure:"health_check_seconds"` // how often to check the model is available; 0 checks only at startup
+	Breaker            BreakerConfig `mapstructure:"breaker"`
+	Cache              CacheConfig   `mapstructure:"cache"`
+}
+
+// CacheConfig sets up the cache of replies to the opening question put to a
+// character
+type CacheConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Path       string `mapstructure:"path"`        // SQLite file the replies are kept in
+	TTLMinutes int    `mapstructure:"ttl_minutes"` // how long a reply is served from the cache, 0 for no limit
+	MaxEntries int    `mapstructure:"max_entries"` // the least recently used replies beyond this are dropped
+}
+
+// BreakerConfig decides when a failing provider is skipped
+type BreakerConfig struct {
+	Failures        int `mapstructure:"failures"`         // timeouts or server errors in a row that open the breaker
+	CooldownSeconds int `mapstructure:"cooldown_seconds"` // how long the provider is skipped once it has
+}
+
+// ReplayConfig sets up the replay provider, which answers without a model
+type ReplayConfig struct {
+	Mode     string `mapstructure:"mode"`     // "record", "playback" or "script"
+	Provider string `mapstructure:"provider"` // the provider whose responses are recorded in record mode
+	Cassette string `mapstructure:"cassette"` // JSON file responses are recorded to and played back from
+	Script   string `mapstructure:"script"`   // YAML file of canned replies per character, for script mode
 }
 
 // New OpenAI config
@@ -24,6 +55,10 @@ type OpenAIConfig struct {
 	BaseURL   string `mapstructure:"base_url"`   // Optional, defaults to OpenAI API
 	MaxTokens int    `mapstructure:"max_tokens"` // Optional, defaults to model's max
 	Timeout   int    `mapstructure:"timeout"`
+
+	JSONSchema bool `mapstructure:"json_schema"` // hold replies to a JSON schema; turn off for servers that only support json_object
+
+	Sampling SamplingConfig `mapstructure:",squash"`
 }
 
 type TtsConfig struct {
@@ -38,10 +73,65 @@ type SstConfig struct {
 	SampleRate   int    `mapstructure:"sample_rate"`
 }
 
+// GameConfig holds the rules of play
+type GameConfig struct {
+	Accusations        AccusationConfig `mapstructure:"accusations"`
+	SessionIdleMinutes int              `mapstructure:"session_idle_minutes"` // games nobody touches for this long are unloaded from memory
+}
+
+// AccusationConfig limits how many accusations a detective may make and
+// what a wrong one costs
+type AccusationConfig struct {
+	Budget         map[string]int `mapstructure:"budget"`          // accusations allowed per mystery difficulty
+	PenaltySeconds int            `mapstructure:"penalty_seconds"` // game time lost per wrong accusation
+	PenaltyScore   int            `mapstructure:"penalty_score"`   // points off the final score per wrong accusation
+}
+
+// BudgetFor returns the number of accusations allowed for a difficulty, at least one
+func (c AccusationConfig) BudgetFor(difficulty string) int {
+	if budget := c.Budget[strings.ToLower(difficulty)]; budget > 0 {
+		return budget
+	}
+	return 1
+}
+
 type OllamaConfig struct {
 	Host    string `mapstructure:"host"`
 	Model   string `mapstructure:"model"`
 	Timeout int    `mapstructure:"timeout"` // seconds
+
+	Sampling SamplingConfig `mapstructure:",squash"`
+}
+
+// SamplingConfig controls how a model picks its words. Unset values are
+// left to the provider.
+type SamplingConfig struct {
+	Temperature *float64 `mapstructure:"temperature" json:"temperature,omitempty"`
+	TopP        *float64 `mapstructure:"top_p" json:"top_p,omitempty"`
+	NumCtx      int      `mapstructure:"num_ctx" json:"num_ctx,omitempty"` // context window in tokens; Ollama only
+	Seed        *int     `mapstructure:"seed" json:"seed,omitempty"`
+	Stop        []string `mapstructure:"stop" json:"stop,omitempty"` // sequences that end the reply
+}
+
+// Merge returns the settings with every value set in override replacing
+// its own
+func (s SamplingConfig) Merge(override SamplingConfig) SamplingConfig {
+	if override.Temperature != nil {
+		s.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		s.TopP = override.TopP
+	}
+	if override.NumCtx > 0 {
+		s.NumCtx = override.NumCtx
+	}
+	if override.Seed != nil {
+		s.Seed = override.Seed
+	}
+	if len(override.Stop) > 0 {
+		s.Stop = override.Stop
+	}
+	return s
 }
 
 func Load() (*Config, error) {
@@ -55,16 +145,32 @@ func Load() (*Config, error) {
 	viper.BindEnv("openai.model", "OPENAI_MODEL")
 	viper.BindEnv("openai.base_url", "OPENAI_BASE_URL")
 	viper.BindEnv("llm.provider", "LLM_PROVIDER")
+	viper.BindEnv("replay.mode", "REPLAY_MODE")
 
-	viper.SetDefault("ollama.host", "http://localhost:11434")
+	// No default host: an empty host falls back to OLLAMA_HOST, then localhost
 	viper.SetDefault("ollama.model", "llama3.2")
-	viper.SetDefault("ollama.timeout", 50)
+	viper.SetDefault("ollama.timeout", 30)
+	viper.SetDefault("ollama.temperature", 0.7)
+	viper.SetDefault("ollama.top_p", 0.9)
 
 	viper.SetDefault("openai.timeout", 30)
 	viper.SetDefault("openai.max_tokens", 1000)
-	viper.SetDefault("ollama.timeout", 30)
+	viper.SetDefault("openai.json_schema", true)
+	viper.SetDefault("openai.temperature", 0.7)
+	viper.SetDefault("openai.top_p", 0.9)
 
 	viper.SetDefault("llm.provider", "openai")
+	viper.SetDefault("llm.health_check_seconds", 60)
+	viper.SetDefault("replay.mode", "playback")
+	viper.SetDefault("replay.provider", "openai")
+	viper.SetDefault("replay.cassette", "data/replay/cassette.json")
+	viper.SetDefault("replay.script", "data/replay/script.yaml")
+	viper.SetDefault("llm.breaker.failures", 3)
+	viper.SetDefault("llm.breaker.cooldown_seconds", 30)
+	viper.SetDefault("llm.cache.enabled", false)
+	viper.SetDefault("llm.cache.path", "llm_cache.db")
+	viper.SetDefault("llm.cache.ttl_minutes", 1440)
+	viper.SetDefault("llm.cache.max_entries", 5000)
 
 	viper.SetDefault("tts.enabled", true)
 	viper.SetDefault("tts.type", "google")
@@ -74,6 +180,11 @@ func Load() (*Config, error) {
 	viper.SetDefault("sst.language_code", "en-US")
 	viper.SetDefault("sst.sample_rate", 16000)
 
+	viper.SetDefault("game.session_idle_minutes", 120)
+	viper.SetDefault("game.accusations.budget", map[string]int{"easy": 5, "medium": 4, "hard": 3})
+	viper.SetDefault("game.accusations.penalty_seconds", 300)
+	viper.SetDefault("game.accusations.penalty_score", 10)
+
 	// Allow environment variables
 	viper.SetEnvPrefix("GOFIGURE")
 	viper.AutomaticEnv()
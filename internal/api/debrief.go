@@ -0,0 +1,240 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/tahcohcat/gofigure-web/internal/auth"
+	"github.com/tahcohcat/gofigure-web/internal/game"
+	"github.com/tahcohcat/gofigure-web/internal/models"
+)
+
+// Debrief is the post-game report for GET /game/{session}/debrief. Unlike
+// everything else sent during a game it includes the full solution.
+type Debrief struct {
+	SessionID   string                   `json:"session_id"`
+	Title       string                   `json:"title"`
+	Solved      bool                     `json:"solved"`
+	Score       int                      `json:"score"`
+	Solution    DebriefSolution          `json:"solution"`
+	Suspects    []SuspectDebrief         `json:"suspects"`
+	Clues       DebriefClues             `json:"clues"`
+	Secrets     DebriefCount             `json:"secrets"`
+	Accusations []game.AccusationAttempt `json:"accusations"`
+	Questions   int                      `json:"questions"`
+	Time        DebriefTime              `json:"time"`
+}
+
+// DebriefSolution is what really happened
+type DebriefSolution struct {
+	Victim   string `json:"victim"`
+	Killer   string `json:"killer"`
+	Weapon   string `json:"weapon"`
+	Location string `json:"location"`
+	Motive   string `json:"motive"`
+}
+
+// SuspectDebrief is everything about one character, and how the detective
+// spent their time with them
+type SuspectDebrief struct {
+	Name        string          `json:"name"`
+	Killer      bool            `json:"killer"`
+	Reliable    bool            `json:"reliable"`
+	Secrets     []DebriefSecret `json:"secrets"`
+	Questions   int             `json:"questions"`
+	TimeSpent   int             `json:"time_spent"` // seconds of game time from questions to this suspect until the next move
+	PeakStress  float64         `json:"peak_stress"`
+	FinalStress float64         `json:"final_stress"`
+}
+
+// DebriefSecret is one secret of a character. A secret counts as uncovered
+// once stress forced it out.
+type DebriefSecret struct {
+	Text      string `json:"text"`
+	Uncovered bool   `json:"uncovered"`
+}
+
+// DebriefClues lists the clues found and the ones that were missed
+type DebriefClues struct {
+	Found  []game.DiscoveredClue `json:"found"`
+	Missed []game.Clue           `json:"missed"`
+}
+
+// DebriefCount compares what was uncovered with what was missed
+type DebriefCount struct {
+	Uncovered int `json:"uncovered"`
+	Missed    int `json:"missed"`
+}
+
+// DebriefTime breaks down the game time in seconds
+type DebriefTime struct {
+	Total     int `json:"total"`
+	Searching int `json:"searching"` // searches, including the time they cost
+	Other     int `json:"other"`     // reading the case, accusations and their penalties
+}
+
+// GET /api/v1/game/{session}/debrief - The full solution and how the investigation went, once the game is over
+func (gh *GameHandler) GetDebrief(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	userID := auth.GetUserIDFromSession(r)
+	session, _, err := gh.loadSession(sessionID, userID)
+	if errors.Is(err, errSessionNotFound) {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, errAccessDenied) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	} else if errors.Is(err, game.ErrUnknownMystery) {
+		http.Error(w, "Mystery not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to restore game: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	session.Lock()
+	defer session.Unlock()
+
+	if !session.GameOver() {
+		http.Error(w, "The debrief is available once the game is over", http.StatusBadRequest)
+		return
+	}
+
+	record, err := gh.userService.GetGameSession(sessionID)
+	if err != nil {
+		log.Printf("Warning: failed to load game outcome: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newDebrief(sessionID, session, record))
+}
+
+// newDebrief builds the report from the session state. record supplies the
+// final outcome and may be nil. The caller holds the session lock.
+func newDebrief(sessionID string, session *GameSession, record *models.UserGameSession) Debrief {
+	murder := session.Murder
+	gameTime := session.GameTime()
+
+	debrief := Debrief{
+		SessionID: sessionID,
+		Title:     murder.Title,
+		Solution: DebriefSolution{
+			Victim:   murder.Victim,
+			Killer:   murder.Killer,
+			Weapon:   murder.Weapon,
+			Location: murder.Location,
+			Motive:   murder.Motive,
+		},
+		Clues: DebriefClues{
+			Found:  session.Clues.Discovered(),
+			Missed: session.Clues.Missed(),
+		},
+		Accusations: session.Accusations,
+		Questions:   session.QuestionsAsked(),
+	}
+	if record != nil && record.Solved != nil {
+		debrief.Solved = *record.Solved
+	}
+	if record != nil && record.Score != nil {
+		debrief.Score = *record.Score
+	}
+
+	timeWith, searching := timeBreakdown(session, int(gameTime.Seconds()))
+	debrief.Time = DebriefTime{Total: int(gameTime.Seconds()), Searching: searching}
+	debrief.Time.Other = debrief.Time.Total - searching
+
+	for i := range murder.Characters {
+		char := &murder.Characters[i]
+		suspect := SuspectDebrief{
+			Name:      char.Name,
+			Killer:    murder.IsKiller(char.Name),
+			Reliable:  char.Reliable,
+			Secrets:   make([]DebriefSecret, len(char.Secrets)),
+			TimeSpent: timeWith[char.Name],
+		}
+		debrief.Time.Other -= suspect.TimeSpent
+
+		for j, secret := range char.Secrets {
+			uncovered := char.IsSecretRevealed(j + 1)
+			suspect.Secrets[j] = DebriefSecret{Text: secret, Uncovered: uncovered}
+			if uncovered {
+				debrief.Secrets.Uncovered++
+			} else {
+				debrief.Secrets.Missed++
+			}
+		}
+
+		if stress, ok := session.Stress.Character(char.Name, gameTime); ok {
+			suspect.FinalStress = stress.Level
+			for _, sample := range stress.History {
+				if sample.Question != "" {
+					suspect.Questions++
+				}
+				if sample.Level > suspect.PeakStress {
+					suspect.PeakStress = sample.Level
+				}
+			}
+		}
+
+		debrief.Suspects = append(debrief.Suspects, suspect)
+	}
+
+	return debrief
+}
+
+// timeBreakdown puts the detective's moves in order and credits the game
+// time from each move to the next to the suspect questioned or to searching
+func timeBreakdown(session *GameSession, end int) (timeWith map[string]int, searching int) {
+	type move struct {
+		at      int
+		suspect string
+		search  bool
+	}
+
+	var moves []move
+	for _, char := range session.Murder.Characters {
+		stress, ok := session.Stress.Character(char.Name, session.GameTime())
+		if !ok {
+			continue
+		}
+		for _, sample := range stress.History {
+			if sample.Question != "" {
+				moves = append(moves, move{at: sample.GameTime, suspect: char.Name})
+			}
+		}
+	}
+	for _, search := range session.Searches {
+		moves = append(moves, move{at: search.GameTime, search: true})
+	}
+	for _, attempt := range session.Accusations {
+		moves = append(moves, move{at: attempt.GameTime})
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].at < moves[j].at })
+
+	timeWith = make(map[string]int)
+	for i, m := range moves {
+		next := end
+		if i+1 < len(moves) {
+			next = moves[i+1].at
+		}
+		spent := next - m.at
+		if spent < 0 {
+			spent = 0
+		}
+
+		switch {
+		case m.search:
+			searching += spent
+		case m.suspect != "":
+			timeWith[m.suspect] += spent
+		}
+	}
+
+	return timeWith, searching
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"time"
+
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
+	"github.com/tahcohcat/gofigure-web/internal/game"
+)
+
+// PublicCharacter is the character card sent to the browser. Knowledge,
+// secrets and reliability stay in the GameSession.
+type PublicCharacter struct {
+	Name   string `json:"name"`
+	Sprite string `json:"sprite,omitempty"`
+	Blurb  string `json:"blurb,omitempty"`
+}
+
+// PublicRoom is a searchable location as the detective sees it. Hidden
+// items are only revealed by searching.
+type PublicRoom struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// StartGameResponse is the payload for POST /game/start. It describes the
+// case as the detective would hear it and never includes the solution.
+type StartGameResponse struct {
+	SessionID  string            `json:"session_id"`
+	Title      string            `json:"title"`
+	Difficulty string            `json:"difficulty"`
+	Intro      string            `json:"intro"`
+	Victim     string            `json:"victim"`
+	Characters []PublicCharacter `json:"characters"`
+	Rooms      []PublicRoom      `json:"rooms,omitempty"`
+
+	AccusationBudget int `json:"accusation_budget"`
+}
+
+// SavedGame is an unfinished game as listed by GET /game/sessions
+type SavedGame struct {
+	SessionID      string    `json:"session_id"`
+	MysteryID      string    `json:"mystery_id"`
+	Title          string    `json:"title"`
+	Difficulty     string    `json:"difficulty"`
+	RemainingTime  int       `json:"remaining_time"`
+	QuestionsAsked int       `json:"questions_asked"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ResumeGameResponse is the payload for POST /game/{session}/resume: the
+// case as at the start, plus everything the detective has done so far
+type ResumeGameResponse struct {
+	StartGameResponse
+	RemainingTime   int                           `json:"remaining_time"`
+	TimerEnabled    bool                          `json:"timer_enabled"`
+	QuestionsAsked  int                           `json:"questions_asked"`
+	AccusationsLeft int                           `json:"accusations_left"`
+	Conversations   map[string][]ConversationLine `json:"conversations"`
+	Clues           []game.DiscoveredClue         `json:"clues"`
+}
+
+// ConversationLine is one line of an interrogation as the detective saw it,
+// without the instructions sent to the model
+type ConversationLine struct {
+	Role      string    `json:"role"` // detective or character
+	Text      string    `json:"text"`
+	Emotion   string    `json:"emotion,omitempty"`
+	Intensity float64   `json:"intensity,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// CharacterStatus is a character card with its current stress, for
+// GET /game/{session}/characters
+type CharacterStatus struct {
+	PublicCharacter
+	StressLevel   float64             `json:"stress_level"`
+	StressState   string              `json:"stress_state"`
+	StressHistory []game.StressSample `json:"stress_history"`
+}
+
+func newCharacterStatus(c *game.Character, stress *game.CharacterStress) CharacterStatus {
+	status := CharacterStatus{
+		PublicCharacter: newPublicCharacter(c),
+		StressState:     game.StressState(0),
+		StressHistory:   []game.StressSample{},
+	}
+
+	if stress != nil {
+		status.StressLevel = stress.Level
+		status.StressState = stress.State
+		status.StressHistory = stress.History
+	}
+
+	return status
+}
+
+func newPublicCharacter(c *game.Character) PublicCharacter {
+	return PublicCharacter{
+		Name:   c.Name,
+		Sprite: c.Sprite,
+		Blurb:  c.Blurb,
+	}
+}
+
+func newStartGameResponse(sessionID string, murder *game.Murder) StartGameResponse {
+	characters := make([]PublicCharacter, len(murder.Characters))
+	for i := range murder.Characters {
+		characters[i] = newPublicCharacter(&murder.Characters[i])
+	}
+
+	rooms := make([]PublicRoom, len(murder.Rooms))
+	for i, room := range murder.Rooms {
+		rooms[i] = PublicRoom{Name: room.Name, Description: room.Description}
+	}
+
+	return StartGameResponse{
+		SessionID:  sessionID,
+		Title:      murder.Title,
+		Difficulty: murder.Difficulty,
+		Intro:      murder.Intro,
+		Victim:     murder.Victim,
+		Characters: characters,
+		Rooms:      rooms,
+	}
+}
+
+// newResumeGameResponse describes a game in progress. The caller holds the
+// session lock.
+func newResumeGameResponse(sessionID string, session *GameSession) ResumeGameResponse {
+	response := ResumeGameResponse{
+		StartGameResponse: newStartGameResponse(sessionID, session.Murder),
+		RemainingTime:     session.RemainingTime(),
+		TimerEnabled:      session.TimerEnabled(),
+		QuestionsAsked:    session.QuestionsAsked(),
+		AccusationsLeft:   session.AccusationsMax - len(session.Accusations),
+		Conversations:     make(map[string][]ConversationLine),
+		Clues:             session.Clues.Discovered(),
+	}
+	response.AccusationBudget = session.AccusationsMax
+
+	for _, char := range session.Murder.Characters {
+		if lines := conversationLines(char.Conversation); len(lines) > 0 {
+			response.Conversations[char.Name] = lines
+		}
+	}
+
+	return response
+}
+
+// conversationLines turns a character's conversation back into what was
+// said, leaving out the system prompt
+func conversationLines(conversation []*game.Message) []ConversationLine {
+	var lines []ConversationLine
+	for _, msg := range conversation {
+		switch msg.Role {
+		case "user":
+			text := msg.Question
+			if text == "" {
+				text = msg.Content
+			}
+			lines = append(lines, ConversationLine{Role: "detective", Text: text, Timestamp: msg.Timestamp})
+		case "assistant":
+			mood := messageEmotion(msg)
+			lines = append(lines, ConversationLine{Role: "character", Text: msg.Content, Emotion: string(mood.Emotion), Intensity: mood.Intensity, Timestamp: msg.Timestamp})
+		}
+	}
+	return lines
+}
+
+// messageEmotion is the canonical emotion of a character's message. Games
+// saved before emotions were normalised may hold any word.
+func messageEmotion(msg *game.Message) emotion.Reading {
+	mood, _ := emotion.Parse(msg.Emotions)
+	if msg.Intensity > 0 {
+		mood.Intensity = msg.Intensity
+	}
+	return mood
+}
@@ -0,0 +1,232 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tahcohcat/gofigure-web/internal/auth"
+	"github.com/tahcohcat/gofigure-web/internal/game"
+	"github.com/tahcohcat/gofigure-web/internal/models"
+)
+
+// Types of game event
+const (
+	EventGameStarted = "game_started"
+	EventResumed     = "resumed"
+	EventQuestion    = "question"
+	EventAnswer      = "answer"
+	EventAnswerError = "answer_error"
+	EventStress      = "stress"
+	EventClue        = "clue"
+	EventSearch      = "search"
+	EventTimer       = "timer"
+	EventAccusation  = "accusation"
+	EventGameOver    = "game_over"
+)
+
+// maxReplayGap caps the pause between two replayed events, so a game that
+// was left overnight doesn't replay overnight too
+const maxReplayGap = 30 * time.Second
+
+// GameEvent is a logged event as sent to the client
+type GameEvent struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Character string          `json:"character,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	GameTime  int             `json:"game_time"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+func newGameEvent(e models.GameEvent) GameEvent {
+	data := json.RawMessage(e.Data)
+	if e.Type == EventAccusation {
+		data = redactAccusation(data)
+	}
+
+	return GameEvent{
+		Seq:       e.Seq,
+		Type:      e.Type,
+		Character: e.Character,
+		Data:      data,
+		GameTime:  e.GameTime,
+		Timestamp: e.CreatedAt,
+	}
+}
+
+// accusationEvent is what the log keeps of an accusation: what the
+// detective guessed and whether each part was right, but never the answers,
+// which would give the solution away while the game is still being played
+type accusationEvent struct {
+	Accusation game.Accusation `json:"accusation"`
+	Result     struct {
+		Suspect  accusationVerdict `json:"suspect"`
+		Weapon   accusationVerdict `json:"weapon"`
+		Location accusationVerdict `json:"location"`
+		Motive   accusationVerdict `json:"motive"`
+		Score    int               `json:"score"`
+		Solved   bool              `json:"solved"`
+	} `json:"result"`
+	GameTime int `json:"game_time"`
+}
+
+// accusationVerdict is whether one part of an accusation was right
+type accusationVerdict struct {
+	Matched string `json:"matched,omitempty"` // what the guess was understood as
+	Correct bool   `json:"correct"`
+}
+
+func newAccusationEvent(attempt game.AccusationAttempt) accusationEvent {
+	event := accusationEvent{Accusation: attempt.Accusation, GameTime: attempt.GameTime}
+	result := attempt.Result
+	event.Result.Suspect = accusationVerdict{Matched: result.Suspect.Matched, Correct: result.Suspect.Correct}
+	event.Result.Weapon = accusationVerdict{Correct: result.Weapon.Correct}
+	event.Result.Location = accusationVerdict{Correct: result.Location.Correct}
+	event.Result.Motive = accusationVerdict{Correct: result.Motive.Correct}
+	event.Result.Score = result.Score
+	event.Result.Solved = result.Solved
+	return event
+}
+
+// redactAccusation drops anything but an accusationEvent from the data of an
+// accusation event, so events logged with the answers don't give them away
+func redactAccusation(data json.RawMessage) json.RawMessage {
+	var event accusationEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return json.RawMessage("{}")
+	}
+	redacted, err := json.Marshal(event)
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return redacted
+}
+
+// recordEvent appends an event to the game log. data is encoded as JSON.
+// Failures are logged and otherwise ignored, like the other game records.
+func (gh *GameHandler) recordEvent(sessionID string, session *GameSession, eventType, character string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Warning: failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	err = gh.userService.AppendGameEvent(&models.GameEvent{
+		SessionID: sessionID,
+		Type:      eventType,
+		Character: character,
+		Data:      string(encoded),
+		GameTime:  int(session.GameTime().Seconds()),
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record %s event: %v", eventType, err)
+	}
+}
+
+// authorizeGameLog checks that the user owns the session in the request,
+// whether or not the game is still loaded
+func (gh *GameHandler) authorizeGameLog(w http.ResponseWriter, r *http.Request) (string, bool) {
+	sessionID := mux.Vars(r)["session"]
+	userID := auth.GetUserIDFromSession(r)
+
+	record, err := gh.userService.GetGameSession(sessionID)
+	if err != nil {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return "", false
+	}
+	if record.UserID != userID {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return "", false
+	}
+
+	return sessionID, true
+}
+
+// GET /api/v1/game/{session}/events - The event log of a game, optionally after a sequence number
+func (gh *GameHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := gh.authorizeGameLog(w, r)
+	if !ok {
+		return
+	}
+
+	after, _ := strconv.Atoi(r.URL.Query().Get("after"))
+
+	logged, err := gh.userService.GetGameEvents(sessionID, after)
+	if err != nil {
+		http.Error(w, "Failed to load game events", http.StatusInternalServerError)
+		return
+	}
+
+	events := make([]GameEvent, len(logged))
+	for i, e := range logged {
+		events[i] = newGameEvent(e)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session_id": sessionID,
+		"events":     events,
+	})
+}
+
+// GET /api/v1/game/{session}/replay - Stream the event log as server-sent
+// events with the original timing; ?speed=4 plays it four times as fast
+func (gh *GameHandler) ReplayEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := gh.authorizeGameLog(w, r)
+	if !ok {
+		return
+	}
+
+	speed := 1.0
+	if s, err := strconv.ParseFloat(r.URL.Query().Get("speed"), 64); err == nil && s > 0 {
+		speed = s
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	logged, err := gh.userService.GetGameEvents(sessionID, 0)
+	if err != nil {
+		http.Error(w, "Failed to load game events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	for i, e := range logged {
+		if i > 0 {
+			gap := e.CreatedAt.Sub(logged[i-1].CreatedAt)
+			if gap > maxReplayGap {
+				gap = maxReplayGap
+			}
+
+			select {
+			case <-time.After(time.Duration(float64(gap) / speed)):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		data, err := json.Marshal(newGameEvent(e))
+		if err != nil {
+			log.Printf("Warning: failed to encode replayed event: %v", err)
+			continue
+		}
+
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Seq, e.Type, data)
+		flusher.Flush()
+	}
+
+	fmt.Fprint(w, "event: end\ndata: {}\n\n")
+	flusher.Flush()
+}
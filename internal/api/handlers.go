@@ -4,90 +4,91 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"math/rand"
 	"net/http"
-	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/tahcohcat/gofigure-web/internal/auth"
 	"github.com/tahcohcat/gofigure-web/internal/game"
+	"github.com/tahcohcat/gofigure-web/internal/llm"
 	"github.com/tahcohcat/gofigure-web/internal/logger"
 	"github.com/tahcohcat/gofigure-web/internal/models"
 	"github.com/tahcohcat/gofigure-web/internal/services"
 )
 
-type GameSession struct {
-	UserID         int
-	Murder         *game.Murder
-	Timer          *time.Ticker
-	RemainingTime  int
-	TimerEnabled   bool
-	GameOver       bool
-	StartedAt      time.Time
-	QuestionsAsked int
-}
+// gameDuration is the length of the game clock in seconds
+const gameDuration = 3600
+
+// searchCost is the game time in seconds it takes to search a room
+const searchCost = 300
+
+// errSessionNotFound is returned when a game session is neither loaded nor saved
+var errSessionNotFound = errors.New("game session not found")
+
+// errAccessDenied is returned when a game session belongs to another user
+var errAccessDenied = errors.New("game session belongs to another user")
 
 type GameHandler struct {
-	sessions           map[string]*GameSession // Store game sessions by ID
-	engine             *game.WebEngine         // Game engine instance
-	userService        *services.UserService   // User service for database operations
+	sessions           *SessionManager       // Games in progress by session ID
+	engine             *game.WebEngine       // Game engine instance
+	mysteries          *game.MysteryRegistry // Mysteries available to play
+	userService        *services.UserService // User service for database operations
 	achievementService *services.AchievementService
 }
 
-func NewGameHandler(userService *services.UserService) *GameHandler {
-	engine, err := game.NewWebEngine()
+func NewGameHandler(userService *services.UserService, mysteries *game.MysteryRegistry) *GameHandler {
+	engine, err := game.NewWebEngine(mysteries.Models)
 	if err != nil {
 		panic("Failed to create web engine: " + err.Error())
 	}
+	return newGameHandler(engine, userService, mysteries)
+}
 
-	achievementService := services.NewAchievementService(userService.GetDB())
+// newGameHandler creates a handler that plays games with engine
+func newGameHandler(engine *game.WebEngine, userService *services.UserService, mysteries *game.MysteryRegistry) *GameHandler {
+	achievementService := services.NewAchievementService(userService.GetDB(), mysteries)
+	if err := achievementService.SeedDefaultAchievements(); err != nil {
+		log.Printf("Warning: failed to seed achievements: %v", err)
+	}
 
-	return &GameHandler{
-		sessions:           make(map[string]*GameSession),
+	gh := &GameHandler{
 		engine:             engine,
+		mysteries:          mysteries,
 		userService:        userService,
 		achievementService: achievementService,
 	}
+
+	idleTimeout := time.Duration(engine.Config().Game.SessionIdleMinutes) * time.Minute
+	gh.sessions = NewSessionManager(idleTimeout, gh.timeUp, gh.evictSession)
+
+	return gh
+}
+
+// Close stops the background work of the handler
+func (gh *GameHandler) Close() {
+	gh.sessions.Close()
+	gh.engine.Close()
+}
+
+// modelReady refuses the request if the model is unavailable, so a game
+// can't start only to fail at the first question
+func (gh *GameHandler) modelReady(w http.ResponseWriter) bool {
+	status := gh.engine.ModelStatus()
+	if !status.Ready {
+		http.Error(w, fmt.Sprintf("Games can't be played right now: the model %s is unavailable (%s)", status.Model, status.Error), http.StatusServiceUnavailable)
+		return false
+	}
+	return true
 }
 
 // GET /api/v1/mysteries - List available mysteries
 func (gh *GameHandler) ListMysteries(w http.ResponseWriter, r *http.Request) {
-	mysteries := []map[string]interface{}{
-		{
-			"id":          "diner_secrets",
-			"title":       "Secrets at Rosie's Diner",
-			"description": "A small-town mystery where everyone has secrets",
-			"difficulty":  "Easy",
-			"file":        "data/mysteries/diner_secrets.json",
-		},
-		{
-			"id":          "blackwood",
-			"title":       "The Blackwood Manor Murder",
-			"description": "A classic manor house mystery with a stormy night setting",
-			"difficulty":  "Medium",
-			"file":        "data/mysteries/blackwood.json",
-		},
-		{
-			"id":          "corporate_betrayal",
-			"title":       "Corporate Betrayal",
-			"description": "A modern office murder involving corporate secrets and embezzlement",
-			"difficulty":  "Medium",
-			"file":        "data/mysteries/corporate_betrayal.json",
-		},
-		{
-			"id":          "cruise_ship",
-			"title":       "Death on the Aurora Star",
-			"description": "A luxury cruise ship mystery with complex motives and alibis",
-			"difficulty":  "Hard",
-			"file":        "data/mysteries/cruise_ship.json",
-		},
-	}
+	mysteries := gh.mysteries.List()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -104,6 +105,10 @@ func (gh *GameHandler) StartGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !gh.modelReady(w) {
+		return
+	}
+
 	var req struct {
 		MysteryID string `json:"mystery_id"`
 	}
@@ -113,79 +118,59 @@ func (gh *GameHandler) StartGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Load the mystery file
-	mysteryFile := filepath.Join("data/mysteries", req.MysteryID+".json")
-	murder, err := game.LoadMurderFromFile(mysteryFile)
-	if err != nil {
+	murder, err := gh.mysteries.Load(req.MysteryID)
+	if errors.Is(err, game.ErrUnknownMystery) {
+		http.Error(w, "Mystery not found", http.StatusNotFound)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to load mystery: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Create and store the game session
 	sessionID := generateSessionID()
-	session := &GameSession{
-		UserID:         userID,
-		Murder:         &murder,
-		RemainingTime:  3600, // 1 hour
-		TimerEnabled:   true,
-		GameOver:       false,
-		StartedAt:      time.Now(),
-		QuestionsAsked: 0,
-	}
-	gh.sessions[sessionID] = session
+	session := newGameSession(userID, req.MysteryID, &murder, time.Now().UnixNano())
+	session.AccusationsMax = gh.engine.Config().Game.Accusations.BudgetFor(murder.Difficulty)
+	gh.sessions.Add(sessionID, session)
 
 	// Record game session start in database
 	if err := gh.userService.CreateGameSession(userID, req.MysteryID, sessionID); err != nil {
 		log.Printf("Warning: failed to record game session start: %v", err)
 	}
 
-	// Start the game timer
-	session.Timer = time.NewTicker(1 * time.Second)
-	go func() {
-		for range session.Timer.C {
-			if session.TimerEnabled && !session.GameOver {
-				session.RemainingTime--
-				if session.RemainingTime <= 0 {
-					session.GameOver = true
-					session.Timer.Stop()
-
-					// Auto-complete the game session as unsolved when time runs out
-					timeSpent := int(time.Since(session.StartedAt).Seconds())
-					if err := gh.userService.CompleteGameSession(sessionID, false, timeSpent, session.QuestionsAsked); err != nil {
-						log.Printf("Warning: failed to complete game session on timeout: %v", err)
-					}
-				}
-			}
-		}
-	}()
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"session_id": sessionID,
+	session.Lock()
+	gh.recordEvent(sessionID, session, EventGameStarted, "", map[string]interface{}{
+		"mystery_id": req.MysteryID,
 		"title":      murder.Title,
-		"intro":      murder.Intro,
-		"characters": murder.Characters,
-		"killer":     murder.Killer, // Include killer info for accusation checking
-		"location":   murder.Location,
-		"weapon":     murder.Weapon,
+		"seed":       session.Seed,
 	})
+	gh.saveSession(sessionID, session)
+	session.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	response := newStartGameResponse(sessionID, session.Murder)
+	response.AccusationBudget = session.AccusationsMax
+
+	json.NewEncoder(w).Encode(response)
 }
 
-// Add to your ask question request struct
 type AskQuestionRequest struct {
-	CharacterName string  `json:"character_name"`
-	Question      string  `json:"question"`
-	CurrentStress float64 `json:"current_stress"`
+	CharacterName string `json:"character_name"`
+	Question      string `json:"question"`
 }
 
 type CharacterResponse struct {
-	Character    string  `json:"character"`
-	Question     string  `json:"question"`
-	Response     string  `json:"response"`
-	Emotion      string  `json:"emotion"`
-	StressLevel  float64 `json:"stress_level"`
-	StressChange float64 `json:"stress_change"`
-	StressState  string  `json:"stress_state"`
+	Character    string                `json:"character"`
+	Question     string                `json:"question"`
+	Response     string                `json:"response"`
+	Emotion      string                `json:"emotion"`         // one of the canonical emotions
+	Intensity    float64               `json:"intensity"`       // how strongly the emotion is felt, from 0 to 1
+	Model        string                `json:"model,omitempty"` // the provider/model that answered
+	StressLevel  float64               `json:"stress_level"`
+	StressChange float64               `json:"stress_change"`
+	StressState  string                `json:"stress_state"`
+	CrackedUnder bool                  `json:"cracked_under_pressure,omitempty"` // A stress rule forced out a secret
+	Clues        []game.DiscoveredClue `json:"clues,omitempty"`                  // Clues this answer uncovered
 }
 
 // POST /api/v1/game/{session}/ask - Ask a character a question
@@ -193,7 +178,7 @@ func (gh *GameHandler) AskCharacter(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionID := vars["session"]
 
-	session, exists := gh.sessions[sessionID]
+	session, exists := gh.sessions.Get(sessionID)
 	if !exists {
 		http.Error(w, "Game session not found", http.StatusNotFound)
 		return
@@ -206,17 +191,128 @@ func (gh *GameHandler) AskCharacter(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if session.GameOver {
-		http.Error(w, "Game is over", http.StatusBadRequest)
-		return
-	}
-
 	var req AskQuestionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	// Use the game engine to get character response
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	response, err := gh.askQuestion(ctx, sessionID, session, req, nil)
+	if err != nil {
+		http.Error(w, err.message, err.status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// askError is a question that could not be answered, with the status to
+// report it with
+type askError struct {
+	status  int
+	message string
+}
+
+// askQuestion puts a question to a character and does all the bookkeeping
+// around it: stress, clues, achievements and the event log. The text of the
+// answer is passed to onText as it is generated when onText is not nil. The
+// session is only locked before and after the model answers, so the rest of
+// the game isn't held up while it does.
+func (gh *GameHandler) askQuestion(ctx context.Context, sessionID string, session *GameSession, req AskQuestionRequest, onText func(text string) error) (*CharacterResponse, *askError) {
+	if status := gh.engine.ModelStatus(); !status.Ready {
+		return nil, &askError{http.StatusServiceUnavailable, fmt.Sprintf("Questions can't be answered right now: the model %s is unavailable (%s)", status.Model, status.Error)}
+	}
+
+	session.Lock()
+	character, exchange, stress, clues, askErr := gh.prepareQuestion(sessionID, session, req)
+	session.Unlock()
+	if askErr != nil {
+		return nil, askErr
+	}
+
+	var reply *llm.CharacterReply
+	var err error
+	if onText == nil {
+		reply, err = gh.engine.AskCharacterQuestion(ctx, exchange)
+	} else {
+		reply, err = gh.engine.AskCharacterQuestionStream(ctx, exchange, onText)
+	}
+
+	session.Lock()
+	defer session.Unlock()
+	defer gh.saveSession(sessionID, session)
+
+	if err != nil {
+		gh.recordEvent(sessionID, session, EventAnswerError, character.Name, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, &askError{http.StatusInternalServerError, "Failed to get character response: " + err.Error()}
+	}
+	if session.GameOver() {
+		return nil, &askError{http.StatusBadRequest, "Game is over"}
+	}
+
+	stress = session.Stress.Apply(character.Name, req.Question, stress, session.GameTime())
+	gh.recordEvent(sessionID, session, EventStress, character.Name, map[string]interface{}{
+		"level":  stress.Level,
+		"change": stress.Change,
+		"state":  stress.State,
+	})
+
+	logger.New().Info(fmt.Sprintf("User %d - Character %s stress: %.1f (change: %+.1f) - State: %s",
+		session.UserID, character.Name, stress.Level, stress.Change, stress.State))
+
+	revealedBefore := len(character.RevealedSecrets)
+	exchange.Apply(reply)
+	crackedUnder := len(character.RevealedSecrets) > revealedBefore
+
+	gh.recordEvent(sessionID, session, EventAnswer, character.Name, map[string]interface{}{
+		"response":               reply.Response,
+		"emotion":                reply.Emotion,
+		"intensity":              reply.Intensity,
+		"model":                  reply.Model,
+		"cracked_under_pressure": crackedUnder,
+	})
+
+	// Clues only count as found once the character has actually answered,
+	// and another question may have found them first
+	var discovered []game.DiscoveredClue
+	for _, clue := range clues {
+		if session.Clues.IsDiscovered(clue.ID) {
+			continue
+		}
+		discovered = append(discovered, gh.discoverClue(sessionID, session, clue, game.QuestionSource(clue)))
+	}
+
+	return &CharacterResponse{
+		Character:    req.CharacterName,
+		Question:     req.Question,
+		Response:     reply.Response,
+		Emotion:      reply.Emotion,
+		Intensity:    reply.Intensity,
+		Model:        reply.Model,
+		StressState:  stress.State,
+		StressChange: stress.Change,
+		StressLevel:  stress.Level,
+		CrackedUnder: crackedUnder,
+		Clues:        discovered,
+	}, nil
+}
+
+// prepareQuestion counts a question and works out what it does to the
+// character before they answer: the stress it puts them under, the clues
+// it brings up and the exchange the model answers. The session must be
+// locked.
+func (gh *GameHandler) prepareQuestion(sessionID string, session *GameSession, req AskQuestionRequest) (*game.Character, *game.Exchange, game.StressReading, []game.Clue, *askError) {
+	if session.GameOver() {
+		return nil, nil, game.StressReading{}, nil, &askError{http.StatusBadRequest, "Game is over"}
+	}
+
 	// Find the character
 	var character *game.Character
 	for i := range session.Murder.Characters {
@@ -227,57 +323,401 @@ func (gh *GameHandler) AskCharacter(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if character == nil {
-		http.Error(w, "Character not found", http.StatusNotFound)
-		return
+		return nil, nil, game.StressReading{}, nil, &askError{http.StatusNotFound, "Character not found"}
 	}
 
 	// Increment questions asked counter
-	session.QuestionsAsked++
+	questionsAsked := session.countQuestion()
 
 	// Record question activity for achievements
 	achievementData := map[string]interface{}{
 		"character":       req.CharacterName,
 		"question":        req.Question,
-		"total_questions": session.QuestionsAsked,
+		"total_questions": questionsAsked,
 	}
 
-	if err := gh.achievementService.CheckAndUpdateAchievements(userID, "question_asked", achievementData); err != nil {
+	if err := gh.achievementService.CheckAndUpdateAchievements(session.UserID, "question_asked", achievementData); err != nil {
 		log.Printf("Warning: failed to check question achievements: %v", err)
 	}
 
-	// Calculate stress response
-	newStressLevel, stressState := calculateStressResponse(req.Question, character, req.CurrentStress)
-	stressChange := newStressLevel - req.CurrentStress
+	gh.recordEvent(sessionID, session, EventQuestion, character.Name, map[string]interface{}{
+		"question": req.Question,
+	})
 
-	// Log the interaction for debugging
-	log.Printf("User %d - Character %s stress: %.1f -> %.1f (change: +%.1f) - State: %s",
-		userID, character.Name, req.CurrentStress, newStressLevel, stressChange, stressState)
+	// The character answers under the stress the question puts them under,
+	// but it only sticks once they have answered
+	stress := session.Stress.Question(character.Name, req.Question, session.GameTime())
 
-	logger.New().Info(fmt.Sprintf("User %d - Character %s stress: %.1f -> %.1f (change: +%.1f) - State: %s",
-		userID, character.Name, req.CurrentStress, newStressLevel, stressChange, stressState))
+	clues := session.Clues.FromQuestion(character.Name, req.Question, stress.Level)
+	turn := game.Turn{Stress: stress.Level, Clues: clues}
+
+	return character, character.Prepare(req.Question, *session.Murder, turn), stress, clues, nil
+}
+
+// GET /api/v1/game/{session}/characters - Current stress and stress history of every character
+func (gh *GameHandler) GetCharacters(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	session, exists := gh.sessions.Get(sessionID)
+	if !exists {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns this session
+	userID := auth.GetUserIDFromSession(r)
+	if session.UserID != userID {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	session.Lock()
+	defer session.Unlock()
+
+	gameTime := session.GameTime()
+	characters := make([]CharacterStatus, 0, len(session.Murder.Characters))
+	for i := range session.Murder.Characters {
+		character := &session.Murder.Characters[i]
+		stress, _ := session.Stress.Character(character.Name, gameTime)
+		characters = append(characters, newCharacterStatus(character, stress))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"game_time":  int(gameTime.Seconds()),
+		"characters": characters,
+	})
+}
+
+type SearchRequest struct {
+	Location string `json:"location"`
+}
+
+type SearchResponse struct {
+	Location      string                `json:"location"`
+	Speaker       string                `json:"speaker"` // Always the narrator, so TTS uses the narrator voice
+	Narration     string                `json:"narration"`
+	Emotion       string                `json:"emotion"`
+	Clues         []game.DiscoveredClue `json:"clues"`
+	TimeCost      int                   `json:"time_cost"`
+	RemainingTime int                   `json:"remaining_time"`
+	GameOver      bool                  `json:"game_over"`
+}
+
+// POST /api/v1/game/{session}/search - Search a room for hidden clues, at the cost of game time
+func (gh *GameHandler) SearchLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	session, exists := gh.sessions.Get(sessionID)
+	if !exists {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns this session
+	userID := auth.GetUserIDFromSession(r)
+	if session.UserID != userID {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	var req SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// The session is only locked around the narration, as it is for a
+	// question, so the rest of the game isn't held up while it's written
+	session.Lock()
+	if session.GameOver() {
+		session.Unlock()
+		http.Error(w, "Game is over", http.StatusBadRequest)
+		return
+	}
+	room, ok := session.Murder.Room(req.Location)
+	if !ok {
+		session.Unlock()
+		http.Error(w, "Location not found", http.StatusNotFound)
+		return
+	}
+	clues := session.Clues.AtLocation(room.Name)
+	murder := *session.Murder
+	session.Unlock()
 
-	// Use the game engine to get character response
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	reply, err := gh.engine.AskCharacterQuestion(ctx, character, req.Question, *session.Murder)
+	narration, emotion := game.SearchNarration(room, clues), "neutral"
+	if reply, err := gh.engine.DescribeSearch(ctx, murder, room, clues); err != nil {
+		log.Printf("Warning: failed to narrate search of %s: %v", room.Name, err)
+	} else {
+		narration, emotion = reply.Response, reply.Emotion
+	}
+
+	session.Lock()
+	defer session.Unlock()
+	defer gh.saveSession(sessionID, session)
+
+	if session.GameOver() {
+		http.Error(w, "Game is over", http.StatusBadRequest)
+		return
+	}
+
+	// A question may have turned up some of the clues in the meantime
+	discovered := make([]game.DiscoveredClue, 0, len(clues))
+	for _, clue := range clues {
+		if session.Clues.IsDiscovered(clue.ID) {
+			continue
+		}
+		discovered = append(discovered, gh.discoverClue(sessionID, session, clue, game.ClueSourceSearch))
+	}
+
+	gh.recordEvent(sessionID, session, EventSearch, "", map[string]interface{}{
+		"location":  room.Name,
+		"narration": narration,
+		"emotion":   emotion,
+		"clues":     len(discovered),
+	})
+
+	// Searching takes time away from interrogating
+	session.Searches = append(session.Searches, searchRecord{Location: room.Name, GameTime: int(session.GameTime().Seconds())})
+	session.clock.Spend(searchCost * time.Second)
+	if session.clock.Expired() {
+		gh.timeUp(sessionID, session)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SearchResponse{
+		Location:      room.Name,
+		Speaker:       "Narrator",
+		Narration:     narration,
+		Emotion:       emotion,
+		Clues:         discovered,
+		TimeCost:      searchCost,
+		RemainingTime: session.RemainingTime(),
+		GameOver:      session.GameOver(),
+	})
+}
+
+// displaySuspect names the accused as the game understood them
+func displaySuspect(result game.AccusationResult) string {
+	if result.Suspect.Matched != "" {
+		return result.Suspect.Matched
+	}
+	return result.Suspect.Guess
+}
+
+// timeUp ends a game that ran out of time and records it as unsolved. It
+// does not need the session lock, and only the first call has any effect.
+func (gh *GameHandler) timeUp(sessionID string, session *GameSession) {
+	if !session.clock.End() {
+		return
+	}
+
+	// Auto-complete the game session as unsolved when time runs out
+	timeSpent := int(session.GameTime().Seconds())
+	if err := gh.userService.CompleteGameSession(sessionID, false, timeSpent, session.QuestionsAsked(), 0); err != nil {
+		log.Printf("Warning: failed to complete game session on timeout: %v", err)
+	}
+
+	gh.recordEvent(sessionID, session, EventGameOver, "", map[string]interface{}{
+		"reason": "time_up",
+		"solved": false,
+		"score":  0,
+	})
+}
+
+// saveSession snapshots the session to the database so the game survives a
+// restart. The caller holds the session lock.
+func (gh *GameHandler) saveSession(sessionID string, session *GameSession) {
+	snapshot, err := session.snapshot(sessionID)
 	if err != nil {
-		http.Error(w, "Failed to get character response: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("Warning: failed to snapshot game session: %v", err)
 		return
 	}
 
-	response := CharacterResponse{
-		Character:    req.CharacterName,
-		Question:     req.Question,
-		Response:     reply.Response,
-		Emotion:      reply.Emotion,
-		StressState:  stressState,
-		StressChange: stressChange,
-		StressLevel:  newStressLevel,
+	if err := gh.userService.SaveGameSnapshot(snapshot); err != nil {
+		log.Printf("Warning: failed to save game session: %v", err)
+	}
+}
+
+// loadSession returns userID's session with the given id, restoring it from
+// its last snapshot when it is not loaded. restored reports whether it was.
+// Another user's session is never restored.
+func (gh *GameHandler) loadSession(sessionID string, userID int) (session *GameSession, restored bool, err error) {
+	if session, exists := gh.sessions.Get(sessionID); exists {
+		if session.UserID != userID {
+			return nil, false, errAccessDenied
+		}
+		return session, false, nil
+	}
+
+	snapshot, err := gh.userService.GetGameSnapshot(sessionID)
+	if err != nil {
+		return nil, false, errSessionNotFound
+	}
+	if snapshot.UserID != userID {
+		return nil, false, errAccessDenied
+	}
+
+	murder, err := gh.mysteries.Load(snapshot.MysteryID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	session, err = restoreGameSession(snapshot, &murder)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Another request may have loaded the game in the meantime
+	loaded := gh.sessions.Add(sessionID, session)
+	return loaded, loaded == session, nil
+}
+
+// evictSession saves an idle session as it is dropped from memory
+func (gh *GameHandler) evictSession(sessionID string, session *GameSession) {
+	session.Lock()
+	defer session.Unlock()
+	gh.saveSession(sessionID, session)
+}
+
+// GET /api/v1/game/sessions - List the user's unfinished games
+func (gh *GameHandler) ListSavedGames(w http.ResponseWriter, r *http.Request) {
+	userID := auth.GetUserIDFromSession(r)
+	if userID == 0 {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	snapshots, err := gh.userService.GetUnfinishedGames(userID)
+	if err != nil {
+		http.Error(w, "Failed to load saved games", http.StatusInternalServerError)
+		return
+	}
+
+	summaries := make(map[string]game.MysterySummary)
+	for _, summary := range gh.mysteries.List() {
+		summaries[summary.ID] = summary
+	}
+
+	games := make([]SavedGame, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		// Games whose mystery has been removed can't be resumed
+		summary, ok := summaries[snapshot.MysteryID]
+		if !ok {
+			continue
+		}
+		games = append(games, SavedGame{
+			SessionID:      snapshot.SessionID,
+			MysteryID:      snapshot.MysteryID,
+			Title:          summary.Title,
+			Difficulty:     summary.Difficulty,
+			RemainingTime:  snapshot.RemainingTime,
+			QuestionsAsked: snapshot.QuestionsAsked,
+			UpdatedAt:      snapshot.UpdatedAt,
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": games,
+	})
+}
+
+// POST /api/v1/game/{session}/resume - Pick up an unfinished game where it was left
+func (gh *GameHandler) ResumeGame(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+	userID := auth.GetUserIDFromSession(r)
+
+	session, restored, err := gh.loadSession(sessionID, userID)
+	if errors.Is(err, errSessionNotFound) {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, errAccessDenied) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	} else if errors.Is(err, game.ErrUnknownMystery) {
+		http.Error(w, "Mystery not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to restore game: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !gh.modelReady(w) {
+		return
+	}
+
+	session.Lock()
+	defer session.Unlock()
+
+	if session.GameOver() {
+		http.Error(w, "Game is already over", http.StatusBadRequest)
+		return
+	}
+
+	if restored {
+		gh.recordEvent(sessionID, session, EventResumed, "", map[string]interface{}{
+			"remaining_time": session.RemainingTime(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newResumeGameResponse(sessionID, session))
+}
+
+// GET /api/v1/game/{session}/clues - Clues discovered so far and where they came from
+func (gh *GameHandler) GetClues(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	session, exists := gh.sessions.Get(sessionID)
+	if !exists {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns this session
+	userID := auth.GetUserIDFromSession(r)
+	if session.UserID != userID {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	session.Lock()
+	defer session.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"clues": session.Clues.Discovered(),
+		"total": session.Clues.Total(),
+	})
+}
+
+// discoverClue marks clue as found in the session and records it in the database
+func (gh *GameHandler) discoverClue(sessionID string, session *GameSession, clue game.Clue, source string) game.DiscoveredClue {
+	found := session.Clues.Discover(clue, source, session.GameTime())
+
+	err := gh.userService.RecordClue(&models.SessionClue{
+		SessionID: sessionID,
+		ClueID:    found.ID,
+		Source:    found.Source,
+		Character: found.Character,
+		Location:  found.Location,
+		GameTime:  found.GameTime,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record clue %s: %v", found.ID, err)
+	}
+
+	gh.recordEvent(sessionID, session, EventClue, found.Character, found)
+
+	return found
 }
 
 // POST /api/v1/game/{session}/accuse - Make an accusation
@@ -285,7 +725,7 @@ func (gh *GameHandler) MakeAccusation(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionID := vars["session"]
 
-	session, exists := gh.sessions[sessionID]
+	session, exists := gh.sessions.Get(sessionID)
 	if !exists {
 		http.Error(w, "Game session not found", http.StatusNotFound)
 		return
@@ -298,58 +738,119 @@ func (gh *GameHandler) MakeAccusation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if session.GameOver {
+	session.Lock()
+	defer session.Unlock()
+
+	if session.GameOver() {
 		http.Error(w, "Game is already over", http.StatusBadRequest)
 		return
 	}
+	defer gh.saveSession(sessionID, session)
 
-	var req struct {
-		Suspect string `json:"suspect"`
-	}
-
+	var req game.Accusation
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	// Check if the accusation is correct
-	correct := req.Suspect == session.Murder.Killer
-
-	// Mark game as over
-	session.GameOver = true
-	if session.Timer != nil {
-		session.Timer.Stop()
+	if req.Suspect == "" {
+		http.Error(w, "Suspect is required", http.StatusBadRequest)
+		return
 	}
 
-	// Calculate time spent
-	timeSpent := int(time.Since(session.StartedAt).Seconds())
+	// Judge the accusation part by part; naming the killer solves the case
+	result := session.Murder.Judge(req)
+	correct := result.Solved
 
-	// Record game completion in database
-	if err := gh.userService.CompleteGameSession(sessionID, correct, timeSpent, session.QuestionsAsked); err != nil {
-		log.Printf("Warning: failed to complete game session: %v", err)
+	attempt := game.AccusationAttempt{Accusation: req, Result: result, GameTime: int(session.GameTime().Seconds())}
+	session.Accusations = append(session.Accusations, attempt)
+
+	err := gh.userService.RecordAccusation(&models.SessionAccusation{
+		SessionID: sessionID,
+		Attempt:   len(session.Accusations),
+		Suspect:   result.Suspect.Matched,
+		Weapon:    req.Weapon,
+		Location:  req.Location,
+		Motive:    req.Motive,
+		Solved:    result.Solved,
+		Score:     result.Score,
+		GameTime:  attempt.GameTime,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to record accusation: %v", err)
+	}
+
+	gh.recordEvent(sessionID, session, EventAccusation, result.Suspect.Matched, newAccusationEvent(attempt))
+
+	// A wrong accusation costs game time; the case stays open while accusations remain
+	penalties := gh.engine.Config().Game.Accusations
+	accusationsLeft := session.AccusationsMax - len(session.Accusations)
+	if !correct && accusationsLeft > 0 {
+		session.clock.Spend(time.Duration(penalties.PenaltySeconds) * time.Second)
+		if !session.clock.Expired() {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"correct":          false,
+				"game_over":        false,
+				"suspect":          result.Suspect.Matched,
+				"accusations_left": accusationsLeft,
+				"time_penalty":     penalties.PenaltySeconds,
+				"remaining_time":   session.RemainingTime(),
+				"message":          fmt.Sprintf("❌ %s is not the killer. You lose %d minutes; %d accusation(s) left.", displaySuspect(result), penalties.PenaltySeconds/60, accusationsLeft),
+			})
+			return
+		}
 	}
 
-	response := map[string]interface{}{
-		"correct":    correct,
-		"killer":     session.Murder.Killer,
-		"weapon":     session.Murder.Weapon,
-		"location":   session.Murder.Location,
-		"time_spent": timeSpent,
-		"questions":  session.QuestionsAsked,
+	// Every wrong accusation comes off the final score
+	score := result.Score - penalties.PenaltyScore*session.WrongAccusations()
+	if score < 0 {
+		score = 0
 	}
 
-	// Record stats
+	// Mark game as over. If the clock ran out first, the game is already lost.
+	if !session.clock.End() {
+		http.Error(w, "Game is already over", http.StatusBadRequest)
+		return
+	}
+
+	// Time spent is game time, so pauses and breaks between sittings don't count
+	timeSpent := int(session.GameTime().Seconds())
+
 	// Record game completion in database
-	if err := gh.userService.CompleteGameSession(sessionID, correct, timeSpent, session.QuestionsAsked); err != nil {
+	if err := gh.userService.CompleteGameSession(sessionID, correct, timeSpent, session.QuestionsAsked(), score); err != nil {
 		log.Printf("Warning: failed to complete game session: %v", err)
 	}
 
+	gh.recordEvent(sessionID, session, EventGameOver, "", map[string]interface{}{
+		"reason": "accusation",
+		"solved": correct,
+		"score":  score,
+	})
+
+	response := map[string]interface{}{
+		"correct":     correct,
+		"game_over":   true,
+		"score":       score,
+		"breakdown":   result,
+		"accusations": session.Accusations,
+		"victim":      session.Murder.Victim,
+		"killer":      session.Murder.Killer,
+		"weapon":      session.Murder.Weapon,
+		"location":    session.Murder.Location,
+		"motive":      session.Murder.Motive,
+		"time_spent":  timeSpent,
+		"questions":   session.QuestionsAsked(),
+		"clues":       session.Clues.Discovered(),
+		"missed":      session.Clues.Missed(),
+	}
+
 	// Record activity
 	mysteryTitle := session.Murder.Title
 	if correct {
 		gh.achievementService.RecordActivity(userID, "mystery_solved",
 			fmt.Sprintf("Solved \"%s\" mystery", mysteryTitle),
-			fmt.Sprintf("Time: %d:%02d, Questions: %d", timeSpent/60, timeSpent%60, session.QuestionsAsked),
+			fmt.Sprintf("Time: %d:%02d, Questions: %d", timeSpent/60, timeSpent%60, session.QuestionsAsked()),
 			"🎯")
 
 		// Check for new personal record
@@ -362,24 +863,28 @@ func (gh *GameHandler) MakeAccusation(w http.ResponseWriter, r *http.Request) {
 	} else {
 		gh.achievementService.RecordActivity(userID, "mystery_attempted",
 			fmt.Sprintf("Attempted \"%s\" mystery", mysteryTitle),
-			fmt.Sprintf("Time: %d:%02d, Questions: %d", timeSpent/60, timeSpent%60, session.QuestionsAsked),
+			fmt.Sprintf("Time: %d:%02d, Questions: %d", timeSpent/60, timeSpent%60, session.QuestionsAsked()),
 			"🎯")
 	}
 
 	// Check and update achievements
 	achievementData := map[string]interface{}{
 		"time_spent":      timeSpent,
-		"questions_asked": session.QuestionsAsked,
-		"mystery_id":      sessionID, // You might want to store mystery ID in session
+		"questions_asked": session.QuestionsAsked(),
+		"mystery_id":      session.MysteryID,
+		"session_id":      sessionID,
 		"correct":         correct,
+		"score":           score,
 	}
 
 	if err := gh.achievementService.CheckAndUpdateAchievements(userID, "mystery_solved", achievementData); err != nil {
 		log.Printf("Warning: failed to check achievements: %v", err)
 	}
 
-	if correct {
-		response["message"] = fmt.Sprintf("🎉 Congratulations! You correctly identified %s as the killer!", session.Murder.Killer)
+	if score == 100 {
+		response["message"] = fmt.Sprintf("🎉 Perfect! %s killed %s with the %s in the %s.", session.Murder.Killer, session.Murder.Victim, session.Murder.Weapon, session.Murder.Location)
+	} else if correct {
+		response["message"] = fmt.Sprintf("🎉 Congratulations! You correctly identified %s as the killer! Your theory scored %d/100.", session.Murder.Killer, score)
 	} else {
 		response["message"] = fmt.Sprintf("❌ Sorry, that's incorrect. The real killer was %s.", session.Murder.Killer)
 	}
@@ -393,7 +898,7 @@ func (gh *GameHandler) GetTimer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionID := vars["session"]
 
-	session, exists := gh.sessions[sessionID]
+	session, exists := gh.sessions.Get(sessionID)
 	if !exists {
 		http.Error(w, "Game session not found", http.StatusNotFound)
 		return
@@ -408,9 +913,9 @@ func (gh *GameHandler) GetTimer(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"remaining_time": session.RemainingTime,
-		"timer_enabled":  session.TimerEnabled,
-		"game_over":      session.GameOver,
+		"remaining_time": session.RemainingTime(),
+		"timer_enabled":  session.TimerEnabled(),
+		"game_over":      session.GameOver(),
 	})
 }
 
@@ -419,7 +924,7 @@ func (gh *GameHandler) ToggleTimer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	sessionID := vars["session"]
 
-	session, exists := gh.sessions[sessionID]
+	session, exists := gh.sessions.Get(sessionID)
 	if !exists {
 		http.Error(w, "Game session not found", http.StatusNotFound)
 		return
@@ -432,11 +937,17 @@ func (gh *GameHandler) ToggleTimer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session.TimerEnabled = !session.TimerEnabled
+	session.Lock()
+	enabled := session.clock.Toggle()
+	gh.recordEvent(sessionID, session, EventTimer, "", map[string]interface{}{
+		"enabled": enabled,
+	})
+	gh.saveSession(sessionID, session)
+	session.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"timer_enabled": session.TimerEnabled,
+		"timer_enabled": enabled,
 	})
 }
 
@@ -666,15 +1177,27 @@ func formatTimeAgo(t time.Time) string {
 	}
 }
 
-func RegisterRoutes(r *mux.Router, userService *services.UserService) *GameHandler {
-	gh := NewGameHandler(userService)
+func RegisterRoutes(r *mux.Router, userService *services.UserService, mysteries *game.MysteryRegistry) *GameHandler {
+	gh := NewGameHandler(userService, mysteries)
 
 	r.HandleFunc("/mysteries", gh.ListMysteries).Methods("GET")
 	r.HandleFunc("/game/start", gh.StartGame).Methods("POST")
+	r.HandleFunc("/game/sessions", gh.ListSavedGames).Methods("GET")
+	r.HandleFunc("/game/{session}/resume", gh.ResumeGame).Methods("POST")
+	r.HandleFunc("/game/{session}/characters", gh.GetCharacters).Methods("GET")
 	r.HandleFunc("/game/{session}/ask", gh.AskCharacter).Methods("POST")
+	r.HandleFunc("/game/{session}/ask/stream", gh.AskCharacterStream).Methods("POST")
+	r.HandleFunc("/game/{session}/ask/ws", gh.AskCharacterSocket).Methods("GET")
+	r.HandleFunc("/game/{session}/clues", gh.GetClues).Methods("GET")
+	r.HandleFunc("/game/{session}/search", gh.SearchLocation).Methods("POST")
 	r.HandleFunc("/game/{session}/accuse", gh.MakeAccusation).Methods("POST")
 	r.HandleFunc("/game/{session}/timer", gh.GetTimer).Methods("GET")
 	r.HandleFunc("/game/{session}/timer/toggle", gh.ToggleTimer).Methods("POST")
+	r.HandleFunc("/game/{session}/debrief", gh.GetDebrief).Methods("GET")
+	r.HandleFunc("/game/{session}/transcript", gh.GetTranscript).Methods("GET")
+	r.HandleFunc("/game/{session}/events", gh.GetEvents).Methods("GET")
+	r.HandleFunc("/game/{session}/replay", gh.ReplayEvents).Methods("GET")
+	r.HandleFunc("/llm/stats", gh.GetLLMStats).Methods("GET")
 	r.HandleFunc("/profile/stats", gh.GetUserStats).Methods("GET")
 
 	r.HandleFunc("/profile/full", gh.GetFullUserProfile).Methods("GET")
@@ -762,87 +1285,3 @@ func ChangePassword(userService *services.UserService) http.HandlerFunc {
 		w.WriteHeader(http.StatusOK)
 	}
 }
-
-// Add stress calculation function
-func calculateStressResponse(question string, character *game.Character, currentStress float64) (float64, string) {
-	questionLower := strings.ToLower(question)
-	stressIncrease := 5.0 // Base stress increase
-
-	// High stress keywords
-	highStressKeywords := []string{
-		"murder", "kill", "weapon", "blood", "death", "guilty",
-		"lie", "alibi", "where were you", "motive", "why did you",
-	}
-
-	// Medium stress keywords
-	mediumStressKeywords := []string{
-		"suspicious", "secret", "hidden", "truth", "evidence",
-		"witness", "saw", "heard", "relationship", "money",
-	}
-
-	// Low stress keywords (calming topics)
-	lowStressKeywords := []string{
-		"weather", "family", "work", "hobby", "general",
-		"hello", "how are", "nice day", "background",
-	}
-
-	// Calculate stress based on keywords
-	for _, keyword := range highStressKeywords {
-		if strings.Contains(questionLower, keyword) {
-			stressIncrease += 15.0
-		}
-	}
-
-	for _, keyword := range mediumStressKeywords {
-		if strings.Contains(questionLower, keyword) {
-			stressIncrease += 8.0
-		}
-	}
-
-	for _, keyword := range lowStressKeywords {
-		if strings.Contains(questionLower, keyword) {
-			stressIncrease = math.Max(1.0, stressIncrease-5.0)
-		}
-	}
-
-	// Character personality modifiers
-	personalityLower := strings.ToLower(character.Personality)
-	if strings.Contains(personalityLower, "nervous") {
-		stressIncrease *= 1.3
-	}
-	if strings.Contains(personalityLower, "calm") {
-		stressIncrease *= 0.7
-	}
-	if strings.Contains(personalityLower, "secretive") {
-		stressIncrease *= 1.2
-	}
-	if strings.Contains(personalityLower, "aggressive") {
-		stressIncrease *= 1.1
-	}
-
-	// Add some randomness
-	randomFactor := (rand.Float64() - 0.5) * 10 // ±5 variation
-	stressIncrease += randomFactor
-
-	// Calculate new stress level
-	newStressLevel := math.Min(100.0, currentStress+stressIncrease)
-
-	// Determine stress state
-	var stressState string
-	switch {
-	case newStressLevel < 25:
-		stressState = "calm"
-	case newStressLevel < 40:
-		stressState = "composed"
-	case newStressLevel < 55:
-		stressState = "nervous"
-	case newStressLevel < 70:
-		stressState = "agitated"
-	case newStressLevel < 85:
-		stressState = "stressed"
-	default:
-		stressState = "nervous"
-	}
-
-	return newStressLevel, stressState
-}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/auth"
+	"github.com/tahcohcat/gofigure-web/internal/database"
+	"github.com/tahcohcat/gofigure-web/internal/game"
+	"github.com/tahcohcat/gofigure-web/internal/models"
+	"github.com/tahcohcat/gofigure-web/internal/services"
+)
+
+// testMystery is the mystery the tests play, from data/mysteries
+const testMystery = "cruise_ship"
+
+// testServer serves the game API with cfg over the mysteries in
+// data/mysteries, with a database of its own
+type testServer struct {
+	t       *testing.T
+	router  *mux.Router
+	handler *GameHandler
+	users   *services.UserService
+}
+
+func newTestServer(t *testing.T, cfg *config.Config) *testServer {
+	t.Helper()
+
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	mysteries, err := game.NewMysteryRegistry(filepath.Join("..", "..", "data", "mysteries"), nil)
+	if err != nil {
+		t.Fatalf("failed to load mysteries: %v", err)
+	}
+
+	users := services.NewUserService(db)
+	auth.Init(users)
+
+	if cfg.Game.SessionIdleMinutes == 0 {
+		cfg.Game.SessionIdleMinutes = 120
+	}
+	gh := newGameHandler(game.NewWebEngineFromConfig(cfg, mysteries.Models), users, mysteries)
+	t.Cleanup(gh.Close)
+
+	router := mux.NewRouter()
+	for _, route := range []struct {
+		path    string
+		handler http.HandlerFunc
+		method  string
+	}{
+		{"/game/start", gh.StartGame, http.MethodPost},
+		{"/game/{session}/resume", gh.ResumeGame, http.MethodPost},
+		{"/game/{session}/ask", gh.AskCharacter, http.MethodPost},
+		{"/game/{session}/accuse", gh.MakeAccusation, http.MethodPost},
+		{"/game/{session}/events", gh.GetEvents, http.MethodGet},
+		{"/game/{session}/replay", gh.ReplayEvents, http.MethodGet},
+		{"/game/{session}/debrief", gh.GetDebrief, http.MethodGet},
+		{"/game/{session}/transcript", gh.GetTranscript, http.MethodGet},
+	} {
+		router.HandleFunc(route.path, route.handler).Methods(route.method)
+	}
+
+	return &testServer{t: t, router: router, handler: gh, users: users}
+}
+
+// login registers a user and returns the cookie of their session
+func (s *testServer) login(username string) *http.Cookie {
+	s.t.Helper()
+
+	user, err := s.users.CreateUser(&models.CreateUserRequest{
+		Username:    username,
+		Email:       username + "@example.com",
+		Password:    "secret123",
+		DisplayName: username,
+	})
+	if err != nil {
+		s.t.Fatalf("failed to create user: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	session, _ := auth.Store.Get(r, "session-name")
+	session.Values["user_id"] = user.ID
+	if err := session.Save(r, w); err != nil {
+		s.t.Fatalf("failed to save session: %v", err)
+	}
+	return w.Result().Cookies()[0]
+}
+
+// do sends a request as the user of cookie and returns the response,
+// decoding its body into out if out is not nil and the request succeeded
+func (s *testServer) do(cookie *http.Cookie, method, path string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			s.t.Fatalf("failed to encode request: %v", err)
+		}
+	}
+
+	r := httptest.NewRequest(method, path, bytes.NewReader(data))
+	r.AddCookie(cookie)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+
+	if out != nil && w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("%s %s: response is not JSON: %v", method, path, err)
+		}
+	}
+	return w
+}
+
+// start starts a game of testMystery and returns its session ID
+func (s *testServer) start(cookie *http.Cookie) string {
+	s.t.Helper()
+
+	var started StartGameResponse
+	if w := s.do(cookie, http.MethodPost, "/game/start", map[string]string{"mystery_id": testMystery}, &started); w.Code != http.StatusOK {
+		s.t.Fatalf("start game: %d %s", w.Code, w.Body)
+	}
+	return started.SessionID
+}
+
+// replayConfig answers questions with the replay provider in mode, recording
+// the offline provider to cassette
+func replayConfig(mode, cassette string) *config.Config {
+	cfg := &config.Config{}
+	cfg.LLM.Provider = "replay"
+	cfg.Replay = config.ReplayConfig{Mode: mode, Provider: "offline", Cassette: cassette}
+	return cfg
+}
+
+func TestRecordAndPlayBack(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	questions := []AskQuestionRequest{
+		{CharacterName: "Captain Rodriguez", Question: "Where were you last night?"},
+		{CharacterName: "Isabella Rossi", Question: "Who was in the galley?"},
+		{CharacterName: "Captain Rodriguez", Question: "Did you see the chef after dinner?"},
+	}
+
+	// play asks every question in a new game and returns the answers
+	play := func(s *testServer, cookie *http.Cookie) []string {
+		sessionID := s.start(cookie)
+
+		var answers []string
+		for _, q := range questions {
+			var reply CharacterResponse
+			if w := s.do(cookie, http.MethodPost, "/game/"+sessionID+"/ask", q, &reply); w.Code != http.StatusOK {
+				t.Fatalf("ask %s %q: %d %s", q.CharacterName, q.Question, w.Code, w.Body)
+			}
+			answers = append(answers, reply.Response)
+		}
+		return answers
+	}
+
+	recorder := newTestServer(t, replayConfig("record", cassette))
+	recorded := play(recorder, recorder.login("recorder"))
+
+	// A fresh game has another seed, so the characters' stress differs
+	player := newTestServer(t, replayConfig("playback", cassette))
+	played := play(player, player.login("player"))
+
+	for i, q := range questions {
+		if played[i] != recorded[i] {
+			t.Errorf("%s, %q: played back %q, recorded %q", q.CharacterName, q.Question, played[i], recorded[i])
+		}
+	}
+}
+
+func TestAccusationEventsKeepTheSolution(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LLM.Provider = "offline"
+	cfg.Game.Accusations.Budget = map[string]int{"hard": 3}
+
+	s := newTestServer(t, cfg)
+	cookie := s.login("detective")
+	sessionID := s.start(cookie)
+
+	murder, err := s.handler.mysteries.Load(testMystery)
+	if err != nil {
+		t.Fatalf("failed to load mystery: %v", err)
+	}
+
+	wrong := game.Accusation{Suspect: "Captain Rodriguez", Weapon: "A meat cleaver", Location: "The galley", Motive: "Money"}
+	var verdict map[string]interface{}
+	if w := s.do(cookie, http.MethodPost, "/game/"+sessionID+"/accuse", wrong, &verdict); w.Code != http.StatusOK {
+		t.Fatalf("accuse: %d %s", w.Code, w.Body)
+	}
+	if verdict["game_over"] != false {
+		t.Fatalf("a wrong accusation with accusations left ended the game: %v", verdict)
+	}
+
+	// An event logged with the whole verdict, as it once was
+	session, _ := s.handler.sessions.Get(sessionID)
+	session.Lock()
+	s.handler.recordEvent(sessionID, session, EventAccusation, "Captain Rodriguez", game.AccusationAttempt{
+		Accusation: wrong,
+		Result:     murder.Judge(wrong),
+	})
+	session.Unlock()
+
+	var logged struct {
+		Events []GameEvent `json:"events"`
+	}
+	w := s.do(cookie, http.MethodGet, "/game/"+sessionID+"/events", nil, &logged)
+	if w.Code != http.StatusOK {
+		t.Fatalf("events: %d %s", w.Code, w.Body)
+	}
+
+	accusations := 0
+	for _, e := range logged.Events {
+		if e.Type != EventAccusation {
+			continue
+		}
+		accusations++
+
+		var event accusationEvent
+		if err := json.Unmarshal(e.Data, &event); err != nil {
+			t.Fatalf("accusation event %s: %v", e.Data, err)
+		}
+		if event.Accusation != wrong || event.Result.Suspect.Correct || event.Result.Suspect.Matched != "Captain Rodriguez" {
+			t.Errorf("accusation event %s doesn't say what was guessed and that it was wrong", e.Data)
+		}
+	}
+	if accusations != 2 {
+		t.Errorf("got %d accusation events, want 2", accusations)
+	}
+
+	for _, answer := range []string{murder.Killer, murder.Weapon, murder.Location, murder.Motive} {
+		if bytes.Contains(w.Body.Bytes(), []byte(answer)) {
+			t.Errorf("the event log gives away %q before the game is over", answer)
+		}
+	}
+}
+
+func TestSessionsStayWithTheirOwner(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LLM.Provider = "offline"
+
+	s := newTestServer(t, cfg)
+	owner, intruder := s.login("owner"), s.login("intruder")
+	sessionID := s.start(owner)
+
+	paths := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/game/" + sessionID + "/resume"},
+		{http.MethodGet, "/game/" + sessionID + "/debrief"},
+		{http.MethodGet, "/game/" + sessionID + "/transcript"},
+	}
+
+	for _, loaded := range []bool{true, false} {
+		if !loaded {
+			// As the sweeper leaves it once the owner has gone idle
+			s.handler.sessions.Remove(sessionID)
+		}
+		for _, p := range paths {
+			if w := s.do(intruder, p.method, p.path, nil, nil); w.Code != http.StatusForbidden {
+				t.Errorf("%s %s (loaded: %v): got %d, want %d", p.method, p.path, loaded, w.Code, http.StatusForbidden)
+			}
+			if _, exists := s.handler.sessions.Get(sessionID); exists != loaded {
+				t.Errorf("%s %s (loaded: %v): another user's request loaded the game", p.method, p.path, loaded)
+			}
+		}
+	}
+
+	// The owner can still pick the game up where they left it
+	if w := s.do(owner, http.MethodPost, "/game/"+sessionID+"/resume", nil, nil); w.Code != http.StatusOK {
+		t.Errorf("resume: %d %s", w.Code, w.Body)
+	}
+	if _, exists := s.handler.sessions.Get(sessionID); !exists {
+		t.Error("resuming didn't load the game")
+	}
+}
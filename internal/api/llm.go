@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tahcohcat/gofigure-web/internal/llm"
+)
+
+// GET /api/v1/llm/stats - How often each model's replies needed a repair prompt or a fallback,
+// the circuit breaker of each provider in the fallback chain and the hit rate of the reply cache
+func (gh *GameHandler) GetLLMStats(w http.ResponseWriter, r *http.Request) {
+	stats := map[string]interface{}{
+		"replies": llm.ReplyStatsByModel(),
+	}
+	if providers := gh.engine.ProviderStatus(); providers != nil {
+		stats["providers"] = providers
+	}
+	if cache := gh.engine.CacheStats(); cache != nil {
+		stats["cache"] = cache
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GET /ready - Readiness probe: 200 when the model is available, 503 when it isn't
+func (gh *GameHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	status := gh.engine.ModelStatus()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
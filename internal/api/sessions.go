@@ -0,0 +1,341 @@
+package api
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tahcohcat/gofigure-web/internal/game"
+)
+
+// sweepInterval is how often the session manager looks for games that ran
+// out of time or were abandoned
+const sweepInterval = 15 * time.Second
+
+// GameSession is one game in progress. Handlers hold the session lock while
+// they read or change game state. The clock has its own lock so the timer
+// can be polled while a character is still answering.
+type GameSession struct {
+	mu sync.Mutex
+
+	UserID         int
+	MysteryID      string
+	Murder         *game.Murder
+	StartedAt      time.Time
+	Seed           int64               // Seeds all session randomness so a game can be reproduced
+	Stress         *game.StressTracker // Server-side stress of every character
+	Clues          *game.ClueTracker   // Clues discovered so far
+	Accusations    []game.AccusationAttempt
+	AccusationsMax int // Accusations allowed before the case is lost
+	Searches       []searchRecord
+
+	clock      *gameClock
+	questions  atomic.Int64
+	lastActive atomic.Int64 // unix nanoseconds of the last request for this session
+}
+
+// searchRecord is one search of a room
+type searchRecord struct {
+	Location string `json:"location"`
+	GameTime int    `json:"game_time"` // seconds of game time elapsed when the search began
+}
+
+func newGameSession(userID int, mysteryID string, murder *game.Murder, seed int64) *GameSession {
+	now := time.Now()
+	s := &GameSession{
+		UserID:    userID,
+		MysteryID: mysteryID,
+		Murder:    murder,
+		StartedAt: now,
+		Seed:      seed,
+		Stress:    game.NewStressTracker(murder, seed),
+		Clues:     game.NewClueTracker(murder),
+		clock:     newGameClock(gameDuration*time.Second, now),
+	}
+	s.touch(now)
+	return s
+}
+
+// Lock locks the game state of the session
+func (s *GameSession) Lock() { s.mu.Lock() }
+
+// Unlock unlocks the game state of the session
+func (s *GameSession) Unlock() { s.mu.Unlock() }
+
+// QuestionsAsked returns the number of questions asked so far
+func (s *GameSession) QuestionsAsked() int {
+	return int(s.questions.Load())
+}
+
+// countQuestion records a question and returns the new total
+func (s *GameSession) countQuestion() int {
+	return int(s.questions.Add(1))
+}
+
+// GameTime returns how much of the game clock has been used
+func (s *GameSession) GameTime() time.Duration {
+	return s.clock.Elapsed()
+}
+
+// RemainingTime returns the seconds left on the game clock
+func (s *GameSession) RemainingTime() int {
+	return int(s.clock.Remaining().Seconds())
+}
+
+// TimerEnabled reports whether the game clock is running
+func (s *GameSession) TimerEnabled() bool {
+	return s.clock.Running()
+}
+
+// GameOver reports whether the game has ended
+func (s *GameSession) GameOver() bool {
+	return s.clock.Over()
+}
+
+// WrongAccusations returns how many accusations named the wrong suspect
+func (s *GameSession) WrongAccusations() int {
+	wrong := 0
+	for _, attempt := range s.Accusations {
+		if !attempt.Result.Solved {
+			wrong++
+		}
+	}
+	return wrong
+}
+
+func (s *GameSession) touch(now time.Time) {
+	s.lastActive.Store(now.UnixNano())
+}
+
+func (s *GameSession) idleSince() time.Time {
+	return time.Unix(0, s.lastActive.Load())
+}
+
+// gameClock works out the remaining game time from timestamps, so no
+// goroutine has to count down every second
+type gameClock struct {
+	mu           sync.Mutex
+	duration     time.Duration
+	used         time.Duration // clock time used before runningSince, penalties included
+	runningSince time.Time     // zero while the clock is paused
+	over         bool
+}
+
+func newGameClock(duration time.Duration, now time.Time) *gameClock {
+	return &gameClock{duration: duration, runningSince: now}
+}
+
+// restoreGameClock rebuilds the clock of a saved game with used time already
+// gone. Time while the game was not loaded does not count.
+func restoreGameClock(duration, used time.Duration, running bool, now time.Time) *gameClock {
+	c := &gameClock{duration: duration, used: used}
+	if c.used > c.duration {
+		c.used = c.duration
+	}
+	if running {
+		c.runningSince = now
+	}
+	return c
+}
+
+func (c *gameClock) elapsed(now time.Time) time.Duration {
+	elapsed := c.used
+	if !c.runningSince.IsZero() {
+		elapsed += now.Sub(c.runningSince)
+	}
+	if elapsed > c.duration {
+		elapsed = c.duration
+	}
+	return elapsed
+}
+
+// Elapsed returns the game time used so far
+func (c *gameClock) Elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.elapsed(time.Now())
+}
+
+// Remaining returns the game time left
+func (c *gameClock) Remaining() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.duration - c.elapsed(time.Now())
+}
+
+// Running reports whether the clock is counting down
+func (c *gameClock) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.runningSince.IsZero()
+}
+
+// Toggle pauses a running clock or restarts a paused one, returning whether it now runs
+func (c *gameClock) Toggle() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.runningSince.IsZero() {
+		if !c.over {
+			c.runningSince = now
+		}
+	} else {
+		c.used = c.elapsed(now)
+		c.runningSince = time.Time{}
+	}
+	return !c.runningSince.IsZero()
+}
+
+// Spend takes d off the remaining time, even while the clock is paused
+func (c *gameClock) Spend(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.used += d
+	if c.used > c.duration {
+		c.used = c.duration
+	}
+}
+
+// Expired reports whether the time is up on a game that has not ended yet
+func (c *gameClock) Expired() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.over && c.elapsed(time.Now()) >= c.duration
+}
+
+// End stops the clock for good. It returns true only for the call that
+// ended the game, so the game is completed exactly once.
+func (c *gameClock) End() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.over {
+		return false
+	}
+	c.used = c.elapsed(time.Now())
+	c.runningSince = time.Time{}
+	c.over = true
+	return true
+}
+
+// Over reports whether the game has ended
+func (c *gameClock) Over() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.over
+}
+
+// SessionManager owns the games loaded in memory. A single sweeper goroutine
+// ends games that ran out of time and drops sessions nobody has used for
+// idleTimeout.
+type SessionManager struct {
+	mu          sync.RWMutex
+	sessions    map[string]*GameSession
+	idleTimeout time.Duration
+	onTimeUp    func(sessionID string, session *GameSession)
+	onEvict     func(sessionID string, session *GameSession)
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewSessionManager starts a session manager. onTimeUp is called for games
+// that run out of time and onEvict for sessions that are dropped as idle, so
+// they can be saved and resumed later.
+func NewSessionManager(idleTimeout time.Duration, onTimeUp, onEvict func(sessionID string, session *GameSession)) *SessionManager {
+	m := &SessionManager{
+		sessions:    make(map[string]*GameSession),
+		idleTimeout: idleTimeout,
+		onTimeUp:    onTimeUp,
+		onEvict:     onEvict,
+		stop:        make(chan struct{}),
+	}
+
+	go m.run()
+	return m
+}
+
+// Add stores a new or resumed session and returns it. If a session with the
+// id is already loaded, that one is kept and returned instead.
+func (m *SessionManager) Add(sessionID string, session *GameSession) *GameSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.sessions[sessionID]; ok {
+		return existing
+	}
+	m.sessions[sessionID] = session
+	return session
+}
+
+// Get returns the session with the given id and marks it as active. A game
+// whose time is up is ended before it is returned.
+func (m *SessionManager) Get(sessionID string) (*GameSession, bool) {
+	m.mu.RLock()
+	session, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	session.touch(time.Now())
+	if session.clock.Expired() {
+		m.onTimeUp(sessionID, session)
+	}
+	return session, true
+}
+
+// Remove forgets a session
+func (m *SessionManager) Remove(sessionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+}
+
+// Close stops the sweeper
+func (m *SessionManager) Close() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+func (m *SessionManager) run() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sweep(time.Now())
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// sweep ends games whose time is up and drops idle sessions
+func (m *SessionManager) sweep(now time.Time) {
+	expired := make(map[string]*GameSession)
+	idle := make(map[string]*GameSession)
+
+	m.mu.Lock()
+	for id, session := range m.sessions {
+		if session.clock.Expired() {
+			expired[id] = session
+		}
+		if m.idleTimeout > 0 && now.Sub(session.idleSince()) > m.idleTimeout {
+			delete(m.sessions, id)
+			idle[id] = session
+		}
+	}
+	m.mu.Unlock()
+
+	for id, session := range expired {
+		m.onTimeUp(id, session)
+	}
+	for id, session := range idle {
+		log.Printf("Dropping idle game session %s", id)
+		m.onEvict(id, session)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tahcohcat/gofigure-web/internal/game"
+	"github.com/tahcohcat/gofigure-web/internal/models"
+)
+
+// sessionState is the part of a GameSession that is saved with a game. The
+// mystery itself is not saved; a resumed game loads it again by id.
+type sessionState struct {
+	Seed           int64                     `json:"seed"`
+	StartedAt      time.Time                 `json:"started_at"`
+	GameTime       time.Duration             `json:"game_time"` // game clock used so far
+	TimerEnabled   bool                      `json:"timer_enabled"`
+	QuestionsAsked int                       `json:"questions_asked"`
+	Stress         game.SavedStress          `json:"stress"`
+	Clues          []game.DiscoveredClue     `json:"clues"`
+	Accusations    []game.AccusationAttempt  `json:"accusations"`
+	AccusationsMax int                       `json:"accusations_max"`
+	Searches       []searchRecord            `json:"searches,omitempty"`
+	Characters     map[string]savedCharacter `json:"characters"`
+}
+
+type savedCharacter struct {
+	Conversation    []savedMessage `json:"conversation"`
+	RevealedSecrets []int          `json:"revealed_secrets,omitempty"`
+}
+
+type savedMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Question  string    `json:"question,omitempty"`
+	Emotions  string    `json:"emotions,omitempty"`
+	Intensity float64   `json:"intensity,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// snapshot captures the state of the session for the database. The caller
+// holds the session lock.
+func (s *GameSession) snapshot(sessionID string) (*models.GameSnapshot, error) {
+	state := sessionState{
+		Seed:           s.Seed,
+		StartedAt:      s.StartedAt,
+		GameTime:       s.GameTime(),
+		TimerEnabled:   s.TimerEnabled(),
+		QuestionsAsked: s.QuestionsAsked(),
+		Stress:         s.Stress.Save(),
+		Clues:          s.Clues.Discovered(),
+		Accusations:    s.Accusations,
+		AccusationsMax: s.AccusationsMax,
+		Searches:       s.Searches,
+		Characters:     make(map[string]savedCharacter),
+	}
+
+	for _, char := range s.Murder.Characters {
+		if len(char.Conversation) == 0 {
+			continue
+		}
+
+		saved := savedCharacter{RevealedSecrets: char.RevealedSecrets}
+		for _, msg := range char.Conversation {
+			saved.Conversation = append(saved.Conversation, savedMessage{
+				Role:      msg.Role,
+				Content:   msg.Content,
+				Question:  msg.Question,
+				Emotions:  msg.Emotions,
+				Intensity: msg.Intensity,
+				Timestamp: msg.Timestamp,
+			})
+		}
+		state.Characters[char.Name] = saved
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode game state: %w", err)
+	}
+
+	return &models.GameSnapshot{
+		SessionID:      sessionID,
+		UserID:         s.UserID,
+		MysteryID:      s.MysteryID,
+		RemainingTime:  s.RemainingTime(),
+		QuestionsAsked: state.QuestionsAsked,
+		State:          string(data),
+	}, nil
+}
+
+// restoreGameSession rebuilds a saved session. murder is a fresh copy of the
+// mystery the game was started with.
+func restoreGameSession(snapshot *models.GameSnapshot, murder *game.Murder) (*GameSession, error) {
+	var state sessionState
+	if err := json.Unmarshal([]byte(snapshot.State), &state); err != nil {
+		return nil, fmt.Errorf("failed to decode game state: %w", err)
+	}
+
+	for i := range murder.Characters {
+		char := &murder.Characters[i]
+		saved, ok := state.Characters[char.Name]
+		if !ok {
+			continue
+		}
+
+		char.RevealedSecrets = saved.RevealedSecrets
+		for _, msg := range saved.Conversation {
+			char.Conversation = append(char.Conversation, &game.Message{
+				Role:      msg.Role,
+				Content:   msg.Content,
+				Question:  msg.Question,
+				Emotions:  msg.Emotions,
+				Intensity: msg.Intensity,
+				Timestamp: msg.Timestamp,
+			})
+		}
+	}
+
+	now := time.Now()
+	session := &GameSession{
+		UserID:         snapshot.UserID,
+		MysteryID:      snapshot.MysteryID,
+		Murder:         murder,
+		StartedAt:      state.StartedAt,
+		Seed:           state.Seed,
+		Stress:         game.RestoreStressTracker(murder, state.Seed, state.Stress),
+		Clues:          game.RestoreClueTracker(murder, state.Clues),
+		Accusations:    state.Accusations,
+		AccusationsMax: state.AccusationsMax,
+		Searches:       state.Searches,
+		clock:          restoreGameClock(gameDuration*time.Second, state.GameTime, state.TimerEnabled, now),
+	}
+	session.questions.Store(int64(state.QuestionsAsked))
+	session.touch(now)
+
+	// A finished game is restored for its debrief and can't be played on
+	if snapshot.Finished {
+		session.clock.End()
+	}
+
+	return session, nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/tahcohcat/gofigure-web/internal/auth"
+)
+
+// Types of message sent while an answer streams in
+const (
+	StreamToken = "token" // the next piece of the answer text
+	StreamDone  = "done"  // the whole answer, with its emotion, stress and clues
+	StreamError = "error" // the character failed to answer
+)
+
+// AskStreamMessage is one message of a streamed answer over a WebSocket
+type AskStreamMessage struct {
+	Type  string             `json:"type"`
+	Text  string             `json:"text,omitempty"`
+	Reply *CharacterResponse `json:"reply,omitempty"`
+	Error string             `json:"error,omitempty"`
+}
+
+// askUpgrader only accepts WebSocket connections from the site itself, since
+// the game is authenticated by cookie
+var askUpgrader = websocket.Upgrader{}
+
+// POST /api/v1/game/{session}/ask/stream - Ask a character a question and
+// stream the answer as server-sent events
+func (gh *GameHandler) AskCharacterStream(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	session, exists := gh.sessions.Get(sessionID)
+	if !exists {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns this session
+	userID := auth.GetUserIDFromSession(r)
+	if session.UserID != userID {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	var req AskQuestionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	// The event stream starts with the first piece of the answer, so a
+	// question that can't be asked at all still gets a plain error status
+	started := false
+	send := func(event string, data interface{}) error {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			started = true
+		}
+
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	response, askErr := gh.askQuestion(ctx, sessionID, session, req, func(text string) error {
+		return send(StreamToken, map[string]string{"text": text})
+	})
+	if askErr != nil {
+		if !started {
+			http.Error(w, askErr.message, askErr.status)
+			return
+		}
+		send(StreamError, map[string]string{"error": askErr.message})
+		return
+	}
+
+	send(StreamDone, response)
+}
+
+// GET /api/v1/game/{session}/ask/ws - Ask questions over a WebSocket. Each
+// question is an AskQuestionRequest and its answer streams back as token
+// messages followed by a done or error message.
+func (gh *GameHandler) AskCharacterSocket(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	session, exists := gh.sessions.Get(sessionID)
+	if !exists {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	}
+
+	// Verify user owns this session
+	userID := auth.GetUserIDFromSession(r)
+	if session.UserID != userID {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	}
+
+	conn, err := askUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Warning: failed to upgrade ask connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var req AskQuestionRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		// Look the session up again, in case it was dropped while idle
+		session, exists := gh.sessions.Get(sessionID)
+		if !exists {
+			conn.WriteJSON(AskStreamMessage{Type: StreamError, Error: "Game session not found"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		response, askErr := gh.askQuestion(ctx, sessionID, session, req, func(text string) error {
+			return conn.WriteJSON(AskStreamMessage{Type: StreamToken, Text: text})
+		})
+		cancel()
+
+		message := AskStreamMessage{Type: StreamDone, Reply: response}
+		if askErr != nil {
+			message = AskStreamMessage{Type: StreamError, Error: askErr.message}
+		}
+		if err := conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tahcohcat/gofigure-web/internal/auth"
+	"github.com/tahcohcat/gofigure-web/internal/game"
+)
+
+// Transcript is every interrogation of a game, grouped by suspect. The
+// solution is only filled in once the game is over.
+type Transcript struct {
+	SessionID  string              `json:"session_id"`
+	Title      string              `json:"title"`
+	Victim     string              `json:"victim"`
+	Finished   bool                `json:"finished"`
+	Solution   *DebriefSolution    `json:"solution,omitempty"`
+	ExportedAt time.Time           `json:"exported_at"`
+	Suspects   []TranscriptSuspect `json:"suspects"`
+}
+
+// TranscriptSuspect is the interrogation of one suspect
+type TranscriptSuspect struct {
+	Name      string               `json:"name"`
+	Exchanges []TranscriptExchange `json:"exchanges"`
+}
+
+// TranscriptExchange is one question and the answer to it
+type TranscriptExchange struct {
+	Question    string    `json:"question"`
+	AskedAt     time.Time `json:"asked_at"`
+	Answer      string    `json:"answer"`
+	Emotion     string    `json:"emotion,omitempty"`
+	Intensity   float64   `json:"intensity,omitempty"`
+	AnsweredAt  time.Time `json:"answered_at,omitempty"`
+	StressLevel float64   `json:"stress_level"`
+	StressState string    `json:"stress_state"`
+}
+
+// GET /api/v1/game/{session}/transcript?format=md|json|html - Export the interrogations
+func (gh *GameHandler) GetTranscript(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["session"]
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "md"
+	}
+	if format != "md" && format != "json" && format != "html" {
+		http.Error(w, "Format must be md, json or html", http.StatusBadRequest)
+		return
+	}
+
+	userID := auth.GetUserIDFromSession(r)
+	session, _, err := gh.loadSession(sessionID, userID)
+	if errors.Is(err, errSessionNotFound) {
+		http.Error(w, "Game session not found", http.StatusNotFound)
+		return
+	} else if errors.Is(err, errAccessDenied) {
+		http.Error(w, "Access denied", http.StatusForbidden)
+		return
+	} else if errors.Is(err, game.ErrUnknownMystery) {
+		http.Error(w, "Mystery not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Failed to restore game: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	session.Lock()
+	transcript := newTranscript(sessionID, session)
+	session.Unlock()
+
+	filename := fmt.Sprintf("transcript-%s.%s", sessionID, format)
+	switch format {
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(transcript)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		if err := transcriptHTML.Execute(w, transcript); err != nil {
+			http.Error(w, "Failed to render transcript", http.StatusInternalServerError)
+		}
+	default:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Write([]byte(transcript.Markdown()))
+	}
+}
+
+// newTranscript builds the transcript of a session. The caller holds the
+// session lock.
+func newTranscript(sessionID string, session *GameSession) Transcript {
+	murder := session.Murder
+	transcript := Transcript{
+		SessionID:  sessionID,
+		Title:      murder.Title,
+		Victim:     murder.Victim,
+		Finished:   session.GameOver(),
+		ExportedAt: time.Now(),
+		Suspects:   []TranscriptSuspect{},
+	}
+
+	if transcript.Finished {
+		transcript.Solution = &DebriefSolution{
+			Victim:   murder.Victim,
+			Killer:   murder.Killer,
+			Weapon:   murder.Weapon,
+			Location: murder.Location,
+			Motive:   murder.Motive,
+		}
+	}
+
+	for i := range murder.Characters {
+		char := &murder.Characters[i]
+		if len(char.Conversation) == 0 {
+			continue
+		}
+
+		// The stress history has one sample per answered question, in the
+		// same order as the conversation
+		var samples []game.StressSample
+		if stress, ok := session.Stress.Character(char.Name, session.GameTime()); ok {
+			for _, sample := range stress.History {
+				if sample.Question != "" {
+					samples = append(samples, sample)
+				}
+			}
+		}
+
+		suspect := TranscriptSuspect{Name: char.Name}
+		next := 0
+		for _, msg := range char.Conversation {
+			switch msg.Role {
+			case "user":
+				exchange := TranscriptExchange{Question: msg.Question, AskedAt: msg.Timestamp}
+				if exchange.Question == "" {
+					exchange.Question = msg.Content
+				}
+				for j := next; j < len(samples); j++ {
+					if samples[j].Question == msg.Question {
+						exchange.StressLevel = samples[j].Level
+						exchange.StressState = game.StressState(samples[j].Level)
+						next = j + 1
+						break
+					}
+				}
+				suspect.Exchanges = append(suspect.Exchanges, exchange)
+			case "assistant":
+				if n := len(suspect.Exchanges); n > 0 {
+					exchange := &suspect.Exchanges[n-1]
+					exchange.Answer = msg.Content
+					mood := messageEmotion(msg)
+					exchange.Emotion = string(mood.Emotion)
+					exchange.Intensity = mood.Intensity
+					exchange.AnsweredAt = msg.Timestamp
+				}
+			}
+		}
+
+		transcript.Suspects = append(transcript.Suspects, suspect)
+	}
+
+	return transcript
+}
+
+// Markdown renders the transcript as a Markdown document
+func (t Transcript) Markdown() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s\n\n", t.Title))
+	b.WriteString(fmt.Sprintf("Transcript of the investigation into the death of %s, exported %s.\n\n",
+		t.Victim, t.ExportedAt.Format(time.RFC1123)))
+
+	if t.Solution != nil {
+		b.WriteString("## Solution\n\n")
+		b.WriteString(fmt.Sprintf("- **Killer:** %s\n", t.Solution.Killer))
+		b.WriteString(fmt.Sprintf("- **Weapon:** %s\n", t.Solution.Weapon))
+		b.WriteString(fmt.Sprintf("- **Location:** %s\n", t.Solution.Location))
+		b.WriteString(fmt.Sprintf("- **Motive:** %s\n\n", t.Solution.Motive))
+	}
+
+	if len(t.Suspects) == 0 {
+		b.WriteString("Nobody has been questioned yet.\n")
+	}
+
+	for _, suspect := range t.Suspects {
+		b.WriteString(fmt.Sprintf("## %s\n\n", suspect.Name))
+		for _, exchange := range suspect.Exchanges {
+			b.WriteString(fmt.Sprintf("**Detective** (%s): %s\n\n", exchange.AskedAt.Format("15:04:05"), exchange.Question))
+			b.WriteString(fmt.Sprintf("**%s** (%s): %s\n\n", suspect.Name, exchange.AnsweredAt.Format("15:04:05"), exchange.Answer))
+			b.WriteString(fmt.Sprintf("*Emotion: %s · Stress: %.0f (%s)*\n\n", exchange.Emotion, exchange.StressLevel, exchange.StressState))
+		}
+	}
+
+	return b.String()
+}
+
+var transcriptHTML = template.Must(template.New("transcript").Funcs(template.FuncMap{
+	"clock": func(t time.Time) string { return t.Format("15:04:05") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - Transcript</title>
+<style>
+body { font-family: Georgia, serif; max-width: 48rem; margin: 2rem auto; color: #22223b; }
+.solution { background: #f2e9e4; padding: 1rem; border-radius: 8px; }
+.detective { margin: 1rem 0 0.25rem; }
+.answer { margin: 0 0 0.25rem 1.5rem; }
+.meta { margin-left: 1.5rem; color: #777; font-size: 0.85rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Transcript of the investigation into the death of {{.Victim}}, exported {{.ExportedAt.Format "Mon, 02 Jan 2006 15:04:05 MST"}}.</p>
+{{with .Solution}}<div class="solution">
+<h2>Solution</h2>
+<p><strong>Killer:</strong> {{.Killer}}<br>
+<strong>Weapon:</strong> {{.Weapon}}<br>
+<strong>Location:</strong> {{.Location}}<br>
+<strong>Motive:</strong> {{.Motive}}</p>
+</div>{{end}}
+{{range .Suspects}}{{$name := .Name}}<h2>{{$name}}</h2>
+{{range .Exchanges}}<p class="detective"><strong>Detective</strong> ({{clock .AskedAt}}): {{.Question}}</p>
+<p class="answer"><strong>{{$name}}</strong> ({{clock .AnsweredAt}}): {{.Answer}}</p>
+<p class="meta">Emotion: {{.Emotion}} · Stress: {{printf "%.0f" .StressLevel}} ({{.StressState}})</p>
+{{end}}{{else}}<p>Nobody has been questioned yet.</p>
+{{end}}</body>
+</html>
+`))
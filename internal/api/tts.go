@@ -9,6 +9,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
 	"github.com/tahcohcat/gofigure-web/internal/game"
 	"github.com/tahcohcat/gofigure-web/internal/tts"
 )
@@ -19,10 +20,11 @@ type TTSHandler struct {
 }
 
 type TTSRequest struct {
-	Text      string `json:"text"`
-	Character string `json:"character"`
-	Emotion   string `json:"emotion"`
-	SessionID string `json:"session_id"` // To get mystery-specific TTS config
+	Text      string  `json:"text"`
+	Character string  `json:"character"`
+	Emotion   string  `json:"emotion"`
+	Intensity float64 `json:"intensity,omitempty"` // how strongly the emotion is felt, from 0 to 1
+	SessionID string  `json:"session_id"`          // To get mystery-specific TTS config
 }
 
 func NewTTSHandler(gameHandler *GameHandler) (*TTSHandler, error) {
@@ -60,9 +62,11 @@ func (th *TTSHandler) SpeakText(w http.ResponseWriter, r *http.Request) {
 	if req.Character == "" {
 		req.Character = "Narrator"
 	}
-	if req.Emotion == "" {
-		req.Emotion = "neutral"
+	mood, _ := emotion.Parse(req.Emotion)
+	if req.Intensity > 0 {
+		mood.Intensity = req.Intensity
 	}
+	req.Emotion = mood.String()
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -148,7 +152,7 @@ func (th *TTSHandler) findTTSModelFromMystery(sessionID, characterName string) g
 	}
 
 	// Get mystery data from game handler
-	session, exists := th.gameHandler.sessions[sessionID]
+	session, exists := th.gameHandler.sessions.Get(sessionID)
 	if !exists {
 		return defaultModel
 	}
@@ -79,24 +79,97 @@ func (db *DB) createTables() error {
 		solved BOOLEAN,
 		time_spent INTEGER,
 		questions_asked INTEGER,
+		score INTEGER,
 		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 	);`
 
+	// Clues discovered during game sessions
+	cluesTable := `
+	CREATE TABLE IF NOT EXISTS session_clues (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		clue_id TEXT NOT NULL,
+		source TEXT NOT NULL, -- question, stress, search
+		character TEXT DEFAULT '',
+		location TEXT DEFAULT '',
+		game_time INTEGER DEFAULT 0,
+		discovered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (session_id, clue_id),
+		FOREIGN KEY (session_id) REFERENCES user_game_sessions(session_id) ON DELETE CASCADE
+	);`
+
+	// Every accusation made during game sessions
+	accusationsTable := `
+	CREATE TABLE IF NOT EXISTS session_accusations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		suspect TEXT NOT NULL,
+		weapon TEXT DEFAULT '',
+		location TEXT DEFAULT '',
+		motive TEXT DEFAULT '',
+		solved BOOLEAN NOT NULL,
+		score INTEGER DEFAULT 0,
+		game_time INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (session_id, attempt),
+		FOREIGN KEY (session_id) REFERENCES user_game_sessions(session_id) ON DELETE CASCADE
+	);`
+
+	// Saved state of games, so they survive a restart and can be resumed
+	snapshotsTable := `
+	CREATE TABLE IF NOT EXISTS game_snapshots (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT UNIQUE NOT NULL,
+		user_id INTEGER NOT NULL,
+		mystery_id TEXT NOT NULL,
+		remaining_time INTEGER DEFAULT 0,
+		questions_asked INTEGER DEFAULT 0,
+		state TEXT NOT NULL, -- JSON encoded game state
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (session_id) REFERENCES user_game_sessions(session_id) ON DELETE CASCADE,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	// Append-only log of everything that happened in a game
+	eventsTable := `
+	CREATE TABLE IF NOT EXISTS game_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		character TEXT DEFAULT '',
+		data TEXT NOT NULL DEFAULT '{}', -- JSON encoded event details
+		game_time INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (session_id, seq),
+		FOREIGN KEY (session_id) REFERENCES user_game_sessions(session_id) ON DELETE CASCADE
+	);`
+
 	// Create indexes for better performance
 	indexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);`,
 		`CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);`,
 		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON user_game_sessions(user_id);`,
 		`CREATE INDEX IF NOT EXISTS idx_sessions_session_id ON user_game_sessions(session_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_session_clues_session_id ON session_clues(session_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_session_accusations_session_id ON session_accusations(session_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_game_snapshots_user_id ON game_snapshots(user_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_game_events_session_id ON game_events(session_id, seq);`,
 	}
 
 	// Execute table creation
-	for _, query := range []string{usersTable, statsTable, sessionsTable} {
+	for _, query := range []string{usersTable, statsTable, sessionsTable, cluesTable, accusationsTable, snapshotsTable, eventsTable} {
 		if _, err := db.Exec(query); err != nil {
 			return fmt.Errorf("failed to create table: %w", err)
 		}
 	}
 
+	// Bring tables created by older versions up to date
+	if err := db.migrateTables(); err != nil {
+		return fmt.Errorf("failed to migrate tables: %w", err)
+	}
+
 	// Create indexes
 	for _, index := range indexes {
 		if _, err := db.Exec(index); err != nil {
@@ -111,6 +184,32 @@ func (db *DB) createTables() error {
 	return nil
 }
 
+// migrateTables adds the columns introduced after a table was first created
+func (db *DB) migrateTables() error {
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"user_game_sessions", "score", "INTEGER"},
+	}
+
+	for _, c := range columns {
+		var count int
+		query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`
+		if err := db.Get(&count, query, c.table, c.column); err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", c.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("failed to add %s.%s: %w", c.table, c.column, err)
+		}
+	}
+
+	return nil
+}
+
 // Database migration for achievement system
 func (db *DB) CreateAchievementTables() error {
 	// Achievements table
@@ -0,0 +1,191 @@
+// Package emotion defines the closed set of emotions characters can show.
+// Whatever word a model picks is mapped onto this set, so the voice, the
+// sprites and the UI only ever have to handle these.
+package emotion
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Emotion is one of the canonical emotions
+type Emotion string
+
+const (
+	Neutral    Emotion = "neutral"
+	Happy      Emotion = "happy"
+	Excited    Emotion = "excited"
+	Sad        Emotion = "sad"
+	Angry      Emotion = "angry"
+	Frustrated Emotion = "frustrated"
+	Nervous    Emotion = "nervous"
+	Worried    Emotion = "worried"
+	Scared     Emotion = "scared"
+	Calm       Emotion = "calm"
+	Confident  Emotion = "confident"
+	Suspicious Emotion = "suspicious"
+	Mysterious Emotion = "mysterious"
+	Defensive  Emotion = "defensive"
+	Surprised  Emotion = "surprised"
+)
+
+// All is every emotion, in the order they are offered to the model
+var All = []Emotion{
+	Neutral, Happy, Excited, Sad, Angry, Frustrated, Nervous, Worried,
+	Scared, Calm, Confident, Suspicious, Mysterious, Defensive, Surprised,
+}
+
+// DefaultIntensity is how strongly an emotion is felt when nothing says otherwise
+const DefaultIntensity = 0.5
+
+// Reading is an emotion and how strongly it is felt, from 0 to 1
+type Reading struct {
+	Emotion   Emotion `json:"emotion"`
+	Intensity float64 `json:"intensity"`
+}
+
+// aliases maps other words for an emotion onto the emotion, with the
+// intensity the word carries
+var aliases = map[string]Reading{
+	"anxious":       {Nervous, 0.6},
+	"uneasy":        {Nervous, 0.4},
+	"tense":         {Nervous, 0.5},
+	"jittery":       {Nervous, 0.6},
+	"panicked":      {Scared, 0.9},
+	"afraid":        {Scared, 0.6},
+	"fearful":       {Scared, 0.6},
+	"frightened":    {Scared, 0.7},
+	"terrified":     {Scared, 0.95},
+	"concerned":     {Worried, 0.4},
+	"troubled":      {Worried, 0.5},
+	"cheerful":      {Happy, 0.6},
+	"glad":          {Happy, 0.4},
+	"pleased":       {Happy, 0.4},
+	"joyful":        {Happy, 0.8},
+	"elated":        {Happy, 0.8},
+	"friendly":      {Happy, 0.3},
+	"amused":        {Happy, 0.4},
+	"energetic":     {Excited, 0.6},
+	"eager":         {Excited, 0.5},
+	"thrilled":      {Excited, 0.9},
+	"melancholy":    {Sad, 0.5},
+	"depressed":     {Sad, 0.8},
+	"grieving":      {Sad, 0.9},
+	"upset":         {Sad, 0.6},
+	"sorrowful":     {Sad, 0.7},
+	"furious":       {Angry, 0.95},
+	"enraged":       {Angry, 0.95},
+	"hostile":       {Angry, 0.7},
+	"irritated":     {Frustrated, 0.4},
+	"annoyed":       {Frustrated, 0.4},
+	"exasperated":   {Frustrated, 0.7},
+	"impatient":     {Frustrated, 0.5},
+	"peaceful":      {Calm, 0.5},
+	"serene":        {Calm, 0.6},
+	"relaxed":       {Calm, 0.5},
+	"composed":      {Calm, 0.5},
+	"authoritative": {Confident, 0.6},
+	"assured":       {Confident, 0.5},
+	"proud":         {Confident, 0.6},
+	"smug":          {Confident, 0.7},
+	"arrogant":      {Confident, 0.8},
+	"wary":          {Suspicious, 0.4},
+	"distrustful":   {Suspicious, 0.6},
+	"skeptical":     {Suspicious, 0.4},
+	"sceptical":     {Suspicious, 0.4},
+	"enigmatic":     {Mysterious, 0.5},
+	"cryptic":       {Mysterious, 0.5},
+	"eerie":         {Mysterious, 0.6},
+	"ominous":       {Mysterious, 0.7},
+	"guarded":       {Defensive, 0.4},
+	"evasive":       {Defensive, 0.5},
+	"indignant":     {Defensive, 0.7},
+	"shocked":       {Surprised, 0.8},
+	"astonished":    {Surprised, 0.8},
+	"startled":      {Surprised, 0.6},
+}
+
+// modifiers are words that make an emotion stronger or weaker
+var modifiers = map[string]float64{
+	"slightly":   0.25,
+	"mildly":     0.25,
+	"somewhat":   0.35,
+	"bit":        0.3,
+	"little":     0.3,
+	"quite":      0.65,
+	"rather":     0.6,
+	"very":       0.8,
+	"really":     0.8,
+	"deeply":     0.85,
+	"highly":     0.85,
+	"extremely":  0.95,
+	"incredibly": 0.95,
+	"utterly":    0.95,
+	"completely": 0.95,
+}
+
+// Parse maps free text such as "anxious" or "very angry" onto a canonical
+// emotion. ok is false, and the reading neutral, if no word is recognised.
+func Parse(text string) (reading Reading, ok bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if r, found := lookup(text); found {
+		return r, true
+	}
+
+	modifier := 0.0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z')
+	}) {
+		if m, found := modifiers[word]; found {
+			modifier = m
+			continue
+		}
+		if r, found := lookup(word); found {
+			if modifier > 0 {
+				r.Intensity = modifier
+			}
+			return r, true
+		}
+	}
+
+	return Reading{Emotion: Neutral, Intensity: DefaultIntensity}, false
+}
+
+func lookup(word string) (Reading, bool) {
+	for _, e := range All {
+		if word == string(e) {
+			return Reading{Emotion: e, Intensity: DefaultIntensity}, true
+		}
+	}
+	r, found := aliases[word]
+	return r, found
+}
+
+// Normalize maps free text onto a canonical emotion, neutral if it isn't
+// recognised
+func Normalize(text string) Emotion {
+	reading, _ := Parse(text)
+	return reading.Emotion
+}
+
+// Names returns the names of all the emotions
+func Names() []string {
+	names := make([]string, len(All))
+	for i, e := range All {
+		names[i] = string(e)
+	}
+	return names
+}
+
+// String describes the reading in words that Parse reads back, such as
+// "very nervous"
+func (r Reading) String() string {
+	switch {
+	case r.Intensity >= 0.75:
+		return fmt.Sprintf("very %s", r.Emotion)
+	case r.Intensity > 0 && r.Intensity <= 0.3:
+		return fmt.Sprintf("slightly %s", r.Emotion)
+	default:
+		return string(r.Emotion)
+	}
+}
@@ -0,0 +1,46 @@
+package emotion
+
+// Prosody is how an emotion changes a voice
+type Prosody struct {
+	SpeakingRate float64 // multiple of the normal speed
+	Pitch        float64 // semitones up or down
+}
+
+// prosodies are the voices of the emotions at DefaultIntensity
+var prosodies = map[Emotion]Prosody{
+	Neutral:    {1.0, 0.0},
+	Happy:      {1.15, 2.0},
+	Excited:    {1.15, 2.0},
+	Sad:        {0.85, -3.0},
+	Angry:      {1.10, -2.0},
+	Frustrated: {1.10, -2.0},
+	Nervous:    {1.20, 3.0},
+	Worried:    {1.20, 3.0},
+	Scared:     {1.20, 3.0},
+	Calm:       {0.95, 0.0},
+	Confident:  {1.0, -1.0},
+	Suspicious: {0.90, -1.5},
+	Mysterious: {0.90, -1.5},
+	Defensive:  {1.05, -1.0},
+	Surprised:  {1.0, 2.0},
+}
+
+// Prosody returns the voice of the reading. A stronger emotion moves the
+// voice further from normal, a weaker one less.
+func (r Reading) Prosody() Prosody {
+	base, ok := prosodies[r.Emotion]
+	if !ok {
+		return prosodies[Neutral]
+	}
+
+	intensity := r.Intensity
+	if intensity <= 0 {
+		intensity = DefaultIntensity
+	}
+	scale := intensity / DefaultIntensity
+
+	return Prosody{
+		SpeakingRate: 1 + (base.SpeakingRate-1)*scale,
+		Pitch:        base.Pitch * scale,
+	}
+}
@@ -0,0 +1,165 @@
+package game
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Points for each part of an accusation; a perfect accusation scores 100
+const (
+	suspectPoints  = 40
+	weaponPoints   = 20
+	locationPoints = 20
+	motivePoints   = 20
+)
+
+// minKeywordOverlap is the share of a guess's keywords that must appear in
+// the answer for a weapon, location or motive to count as right
+const minKeywordOverlap = 0.5
+
+// Accusation is the detective's theory of the murder, Cluedo style
+type Accusation struct {
+	Suspect  string `json:"suspect"`
+	Weapon   string `json:"weapon"`
+	Location string `json:"location"`
+	Motive   string `json:"motive"`
+}
+
+// AccusationPart is the verdict on one part of an accusation
+type AccusationPart struct {
+	Guess   string `json:"guess"`
+	Matched string `json:"matched,omitempty"` // what the guess was understood as
+	Answer  string `json:"answer"`
+	Correct bool   `json:"correct"`
+	Points  int    `json:"points"`
+}
+
+// AccusationResult breaks an accusation down part by part. The case counts
+// as solved when the suspect is right; the other parts add to the score.
+type AccusationResult struct {
+	Suspect  AccusationPart `json:"suspect"`
+	Weapon   AccusationPart `json:"weapon"`
+	Location AccusationPart `json:"location"`
+	Motive   AccusationPart `json:"motive"`
+	Score    int            `json:"score"` // 0-100
+	Solved   bool           `json:"solved"`
+}
+
+// AccusationAttempt is one accusation made during a game
+type AccusationAttempt struct {
+	Accusation Accusation       `json:"accusation"`
+	Result     AccusationResult `json:"result"`
+	GameTime   int              `json:"game_time"` // seconds of game time elapsed
+}
+
+// Judge scores an accusation against the solution. Suspect names are fuzzy
+// matched against the characters, so "lady b" accuses Lady Blackwood.
+func (m *Murder) Judge(a Accusation) AccusationResult {
+	result := AccusationResult{
+		Suspect:  AccusationPart{Guess: a.Suspect, Answer: m.Killer},
+		Weapon:   AccusationPart{Guess: a.Weapon, Answer: m.Weapon},
+		Location: AccusationPart{Guess: a.Location, Answer: m.Location},
+		Motive:   AccusationPart{Guess: a.Motive, Answer: m.Motive},
+	}
+
+	result.Suspect.Matched = m.matchCharacter(a.Suspect)
+	result.Suspect.Correct = m.IsKiller(result.Suspect.Matched)
+	result.Weapon.Correct = keywordOverlap(a.Weapon, m.Weapon) >= minKeywordOverlap
+	result.Location.Correct = keywordOverlap(a.Location, m.Location) >= minKeywordOverlap
+	result.Motive.Correct = keywordOverlap(a.Motive, m.Motive) >= minKeywordOverlap
+
+	award := func(part *AccusationPart, points int) {
+		if part.Correct {
+			part.Points = points
+			result.Score += points
+		}
+	}
+	award(&result.Suspect, suspectPoints)
+	award(&result.Weapon, weaponPoints)
+	award(&result.Location, locationPoints)
+	award(&result.Motive, motivePoints)
+
+	result.Solved = result.Suspect.Correct
+	return result
+}
+
+// matchCharacter returns the character name closest to guess, or "" if
+// nothing is close
+func (m *Murder) matchCharacter(guess string) string {
+	guess = strings.TrimSpace(guess)
+	if guess == "" {
+		return ""
+	}
+
+	for _, char := range m.Characters {
+		if strings.EqualFold(char.Name, guess) {
+			return char.Name
+		}
+	}
+
+	return m.closesCharacterMatches().Closest(guess)
+}
+
+// stopWords are left out when comparing free-text guesses
+var stopWords = map[string]bool{
+	"the": true, "and": true, "was": true, "with": true, "his": true, "her": true,
+	"for": true, "that": true, "had": true, "been": true, "from": true, "into": true,
+	"they": true, "she": true, "him": true, "who": true, "would": true, "about": true,
+}
+
+// keywordOverlap returns the share of guess's keywords found in answer.
+// Words match when they share a stem, so "embezzlement" matches "embezzling".
+func keywordOverlap(guess, answer string) float64 {
+	guessWords := keywords(guess)
+	if len(guessWords) == 0 {
+		return 0
+	}
+	answerWords := keywords(answer)
+
+	found := 0
+	for _, g := range guessWords {
+		for _, a := range answerWords {
+			if sameStem(g, a) {
+				found++
+				break
+			}
+		}
+	}
+
+	return float64(found) / float64(len(guessWords))
+}
+
+func keywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var words []string
+	for _, field := range fields {
+		if len(field) > 2 && !stopWords[field] {
+			words = append(words, field)
+		}
+	}
+	return words
+}
+
+// stemEndings are what may follow a shared stem of five letters or more, so
+// "embezzling" matches "embezzlement" but "medical" doesn't match "medications"
+var stemEndings = map[string]bool{
+	"": true, "e": true, "s": true, "es": true, "ed": true, "er": true, "ers": true,
+	"ing": true, "ings": true, "ment": true, "ments": true, "ement": true,
+	"ion": true, "ions": true, "ation": true, "ations": true,
+	"y": true, "ies": true, "ied": true, "ly": true, "ance": true, "ence": true,
+}
+
+func sameStem(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n >= 5 && stemEndings[a[n:]] && stemEndings[b[n:]]
+}
@@ -0,0 +1,123 @@
+package game
+
+import "testing"
+
+// testSolution is the solution of the cruise ship mystery
+var testSolution = Murder{
+	Killer:   "Dr. Sarah Chen",
+	Weapon:   "Hypothermia (locked in freezer)",
+	Location: "Ship's Cold Storage Freezer",
+	Motive:   "Marcus discovered Dr. Chen was smuggling rare medications off the ship and threatened to expose her illegal operation",
+	Characters: []Character{
+		{Name: "Captain Rodriguez"},
+		{Name: "Isabella Rossi"},
+		{Name: "Dr. Sarah Chen"},
+		{Name: "Antonio Silva"},
+	},
+}
+
+func TestJudge(t *testing.T) {
+	tests := []struct {
+		name       string
+		accusation Accusation
+		matched    string
+		correct    [4]bool // suspect, weapon, location, motive
+		score      int
+	}{
+		{"exact", Accusation{
+			Suspect:  "Dr. Sarah Chen",
+			Weapon:   "Hypothermia (locked in freezer)",
+			Location: "Ship's Cold Storage Freezer",
+			Motive:   "Marcus discovered Dr. Chen was smuggling rare medications off the ship and threatened to expose her illegal operation",
+		}, "Dr. Sarah Chen", [4]bool{true, true, true, true}, 100},
+		{"close", Accusation{
+			Suspect:  "sarah chen",
+			Weapon:   "Locked in the freezer",
+			Location: "the cold storage",
+			Motive:   "He found out she smuggled medication",
+		}, "Dr. Sarah Chen", [4]bool{true, true, true, true}, 100},
+		{"wrong", Accusation{
+			Suspect:  "Captain Rodriguez",
+			Weapon:   "A meat cleaver",
+			Location: "The galley",
+			Motive:   "Medical malpractice",
+		}, "Captain Rodriguez", [4]bool{}, 0},
+		{"half right", Accusation{
+			Suspect: "Dr Chen",
+			Weapon:  "hypothermia",
+		}, "Dr. Sarah Chen", [4]bool{true, true, false, false}, suspectPoints + weaponPoints},
+		{"empty", Accusation{}, "", [4]bool{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := testSolution.Judge(tt.accusation)
+
+			if result.Suspect.Matched != tt.matched {
+				t.Errorf("suspect matched %q, want %q", result.Suspect.Matched, tt.matched)
+			}
+			parts := []AccusationPart{result.Suspect, result.Weapon, result.Location, result.Motive}
+			for i, part := range parts {
+				if part.Correct != tt.correct[i] {
+					t.Errorf("%q against %q: got correct %v, want %v", part.Guess, part.Answer, part.Correct, tt.correct[i])
+				}
+			}
+			if result.Score != tt.score {
+				t.Errorf("got score %d, want %d", result.Score, tt.score)
+			}
+			if result.Solved != tt.correct[0] {
+				t.Errorf("got solved %v, want %v", result.Solved, tt.correct[0])
+			}
+		})
+	}
+}
+
+func TestKeywordOverlap(t *testing.T) {
+	tests := []struct {
+		guess  string
+		answer string
+		want   float64
+	}{
+		{"Candlestick", "Candlestick", 1},
+		{"the CANDLESTICK!", "Candlestick", 1},
+		{"embezzlement", "Marcus had been embezzling funds", 1},
+		{"embezzlement and blackmail", "Marcus had been embezzling funds", 0.5},
+		{"cast iron pan", "Blunt force trauma (cast iron skillet)", 2.0 / 3},
+		{"Library", "Study", 0},
+		{"the and was", "Library", 0},
+		{"", "Library", 0},
+	}
+
+	for _, tt := range tests {
+		if got := keywordOverlap(tt.guess, tt.answer); got != tt.want {
+			t.Errorf("keywordOverlap(%q, %q) = %v, want %v", tt.guess, tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestSameStem(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"poison", "poison", true},
+		{"poisoned", "poison", true},
+		{"embezzling", "embezzlement", true},
+		{"smuggling", "smuggled", true},
+		{"discovered", "discovery", true},
+		{"freezer", "freezing", true},
+		{"medical", "medications", false},
+		{"revolver", "revolution", false},
+		{"candle", "candlestick", false},
+		{"debt", "debts", false}, // too short to stem
+	}
+
+	for _, tt := range tests {
+		if got := sameStem(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameStem(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := sameStem(tt.b, tt.a); got != tt.want {
+			t.Errorf("sameStem(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
@@ -3,11 +3,12 @@ package game
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
 	"github.com/tahcohcat/gofigure-web/internal/llm"
 	"github.com/tahcohcat/gofigure-web/internal/logger"
 )
@@ -16,7 +17,9 @@ type Message struct {
 	Role      string    `json:"role,omitempty"`
 	Content   string    `json:"content,omitempty" json:"content,omitempty"`
 	Emotions  string    `json:"emotions,omitempty"`
+	Intensity float64   `json:"intensity,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+	Question  string    `json:"-"` // the detective's own words, without the instructions around them
 }
 
 type TTS struct {
@@ -26,73 +29,204 @@ type TTS struct {
 
 // Character in the game
 type Character struct {
-	Name        string   `json:"name"`
-	Personality string   `json:"personality"`
-	Sprite      string   `json:"sprite,omitempty"`
-	Knowledge   []string `json:"knowledge"`
-	Reliable    bool     `json:"reliable"`
-	TTS         []TTS    `json:"tts"`
-
-	Conversation []*Message
+	Name        string        `json:"name"`
+	Personality string        `json:"personality"`
+	Sprite      string        `json:"sprite,omitempty"`
+	Blurb       string        `json:"blurb,omitempty"`
+	Knowledge   []string      `json:"knowledge"`
+	Reliable    bool          `json:"reliable"`
+	Secrets     []string      `json:"secrets,omitempty"`
+	Stress      StressProfile `json:"stress,omitempty"`
+	TTS         []TTS         `json:"tts"`
+	LLM         llm.Options   `json:"llm,omitempty"` // model and sampling settings for this character only
+
+	Conversation    []*Message
+	RevealedSecrets []int // 1-based indexes into Secrets that stress has forced out and the character has told
 }
 
+// lostThread is what a character says when the model's reply can't be used
+const lostThread = "I... I'm sorry, I lost my train of thought. Could you ask me that again?"
+
+// GetCharacterResponse sends a one-shot prompt and checks the reply
 func (c *Character) GetCharacterResponse(ctx context.Context, prompt string, llmClient llm.LLM) (*llm.CharacterReply, error) {
+	req := llm.Prompt(prompt, c.LLM)
+	req.Question = &llm.Question{Character: c.Name, Persona: c.persona(Turn{}, nil)}
 
-	resp, err := llmClient.GenerateResponse(ctx, prompt)
+	resp, err := llmClient.GenerateResponse(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
-	var reply llm.CharacterReply
-	if err := json.Unmarshal([]byte(resp), &reply); err != nil {
-		logger.New().Warn(fmt.Sprintf("failed to unmarshal response. [response:%s, prompt:%s]", resp, prompt))
+	reply := c.checkedReply(ctx, llmClient, req, resp)
+	if reply.Response == "" {
+		return nil, fmt.Errorf("no usable reply from %s", reply.Model)
+	}
+	return reply, nil
+}
 
-		// Try to extract JSON from the response if it's embedded in text
-		if extractedReply, extractErr := c.extractJSONFromResponse(resp); extractErr == nil {
-			return extractedReply, nil
-		}
+// checkedReply validates the reply of the model to req. An invalid
+// reply is sent back once with a repair prompt, and if that fails too
+// whatever can be salvaged is used. Every retry and fallback is counted
+// against the model that answered, which the reply names.
+func (c *Character) checkedReply(ctx context.Context, llmClient llm.LLM, req llm.Request, resp llm.Response) *llm.CharacterReply {
+	model := resp.Model
+
+	reply, problem := llm.ParseReply(resp.Text)
+	if problem == nil {
+		llm.RecordReply(model, llm.ReplyValid)
+		reply.Model = model
+		return reply
+	}
 
-		// Fallback: create a valid reply from the raw response
-		return &llm.CharacterReply{
-			Response: resp,
-			Emotion:  "neutral", // Default emotion
-		}, nil
+	logger.New().Warn(fmt.Sprintf("invalid reply, asking for a repair. [character:%s, model:%s, problem:%v]", c.Name, model, problem))
+
+	messages := req.Messages
+	repair := llm.Request{
+		Messages: append(messages[:len(messages):len(messages)],
+			llm.ChatMessage{Role: llm.RoleAssistant, Content: resp.Text},
+			llm.ChatMessage{Role: llm.RoleUser, Content: llm.RepairPrompt(problem)},
+		),
+		Options:  req.Options,
+		Question: req.Question,
 	}
-	return &reply, nil
+
+	text := resp.Text
+	repaired, err := llmClient.Chat(ctx, repair)
+	if err == nil {
+		if reply, problem = llm.ParseReply(repaired.Text); problem == nil {
+			llm.RecordReply(model, llm.ReplyRepaired)
+			reply.Model = model
+			return reply
+		}
+		text = repaired.Text
+	}
+
+	logger.New().Warn(fmt.Sprintf("repair failed, falling back. [character:%s, model:%s, problem:%v, error:%v]", c.Name, model, problem, err))
+	llm.RecordReply(model, llm.ReplyFallback)
+
+	reply = llm.FallbackReply(text)
+	reply.Model = model
+	return reply
+}
+
+// Turn is the game state that shapes a single answer
+type Turn struct {
+	Stress float64 // the character's current stress level, decides which stress rules apply
+	Clues  []Clue  // clues the answer should bring to light
 }
 
 // AskQuestion using Ollama client for character interaction
-func (c *Character) AskQuestion(ctx context.Context, question string, murder Murder, llmClient llm.LLM) (*llm.CharacterReply, error) {
+func (c *Character) AskQuestion(ctx context.Context, question string, murder Murder, turn Turn, llmClient llm.LLM) (*llm.CharacterReply, error) {
+	return c.AskQuestionStream(ctx, question, murder, turn, llmClient, nil)
+}
 
-	c.addQuestion(question, murder)
+// AskQuestionStream is AskQuestion that passes the text of the answer to
+// onText as it is generated. With a nil onText the reply is not streamed.
+// The question and answer only join the conversation once the whole reply
+// has arrived.
+func (c *Character) AskQuestionStream(ctx context.Context, question string, murder Murder, turn Turn, llmClient llm.LLM, onText func(text string) error) (*llm.CharacterReply, error) {
+	exchange := c.Prepare(question, murder, turn)
+	reply, err := exchange.Ask(ctx, llmClient, onText)
+	if err != nil {
+		return &llm.CharacterReply{}, err
+	}
+	exchange.Apply(reply)
+	return reply, nil
+}
 
-	prompt := c.serialiseConversation()
+// Exchange is a question put to a character, from when it is asked until
+// the answer joins the conversation. Ask only uses what Prepare took from
+// the character, so the game needn't stay locked while the model answers;
+// Prepare and Apply change or read the character and must not.
+type Exchange struct {
+	character *Character
+	mystery   string
+	question  string
+	rules     []StressRule
+	persona   *llm.Persona
+	pending   []*Message // the messages that put the question
+	messages  []llm.ChatMessage
+}
 
-	resp, err := c.GetCharacterResponse(ctx, prompt, llmClient)
+// Prepare puts question to the character as they are now
+func (c *Character) Prepare(question string, murder Murder, turn Turn) *Exchange {
+	rules := c.Stress.ActiveRules(turn.Stress)
+	pending := c.questionMessages(question, murder, turn, rules)
+	return &Exchange{
+		character: c,
+		mystery:   murder.ID,
+		question:  question,
+		rules:     rules,
+		persona:   c.persona(turn, rules),
+		pending:   pending,
+		messages:  c.chatMessages(pending),
+	}
+}
+
+// Ask has the model answer the question, passing the text of the answer to
+// onText as it is generated if onText is not nil
+func (x *Exchange) Ask(ctx context.Context, llmClient llm.LLM, onText func(text string) error) (*llm.CharacterReply, error) {
+	c := x.character
+	req := llm.Request{
+		Messages: x.messages,
+		Options:  c.LLM,
+		Question: &llm.Question{Mystery: x.mystery, Character: c.Name, Text: x.question, Persona: x.persona},
+	}
+
+	var resp llm.Response
+	var err error
+	if onText == nil {
+		resp, err = llmClient.Chat(ctx, req)
+	} else {
+		resp, err = llm.ChatStream(ctx, llmClient, req, newResponseStream(onText).write)
+	}
 	if err != nil {
 		logger.New().WithError(err).Warn("could not generate character response")
-		return &llm.CharacterReply{}, err
+		return nil, err
+	}
+
+	reply := c.checkedReply(ctx, llmClient, req, resp)
+	if reply.Response == "" {
+		reply.Response = lostThread
+	}
+	return reply, nil
+}
+
+// Apply adds the question and reply to the conversation and records the
+// secrets the reply told
+func (x *Exchange) Apply(reply *llm.CharacterReply) {
+	c := x.character
+
+	// Another question may have opened the conversation in the meantime
+	pending := x.pending
+	if pending[0].Role == llm.RoleSystem && !c.IsInitialMessage() {
+		pending = pending[1:]
 	}
 
+	c.Conversation = append(c.Conversation, pending...)
 	c.Conversation = append(c.Conversation, &Message{
 
 		// openai supperted types:  ['system', 'assistant', 'user', 'function', 'tool', and 'developer']",
-		Role:      "assistant",
-		Content:   resp.Response,
-		Emotions:  resp.Emotion,
+		Role:      llm.RoleAssistant,
+		Content:   reply.Response,
+		Emotions:  reply.Emotion,
+		Intensity: reply.Intensity,
 		Timestamp: time.Now(),
 	})
 
-	return resp, nil
+	c.recordRevealedSecrets(x.rules, reply.Response)
 }
 
-func (c *Character) addQuestion(question string, murder Murder) {
+// questionMessages builds the messages that put question to the character:
+// the scenario first if this is the opening question, then the question
+func (c *Character) questionMessages(question string, murder Murder, turn Turn, rules []StressRule) []*Message {
+	var messages []*Message
 	reliabilityNote := "You are generally truthful and helpful."
 	if !c.Reliable {
 		reliabilityNote = "You might hide some facts, be evasive, or provide misleading information. Stay in character."
 	}
 
-	latest := fmt.Sprintf("Detective's follow up question: %s\n\nIMPORTANT: You MUST respond in this exact JSON format: {\"response\": \"your character response here\", \"emotion\": \"your emotional state\"}", question)
+	latest := fmt.Sprintf("Detective's follow up question: %s\n\nIMPORTANT: You MUST respond in this exact JSON format: {\"response\": \"your character response here\", \"emotion\": \"your emotional state\", \"intensity\": 0.5}", question)
 
 	if c.IsInitialMessage() {
 		scenario := fmt.Sprintf(`You are roleplaying as %s in a murder mystery game.
@@ -103,10 +237,12 @@ CHARACTER PROFILE:
 - %s
 
 MURDER SCENARIO:
+- Victim: %s
 - Victim found in: %s
 - Murder weapon: %s  
 - Actual killer: %s
 - Your knowledge about the case: %v
+%s
 
 CRITICAL INSTRUCTIONS:
 - Stay completely in character
@@ -115,54 +251,181 @@ CRITICAL INSTRUCTIONS:
 - Don't break character or mention this is a game
 - If you don't know something, say so in character
 - You MUST respond in valid JSON format only
-- Reply in this EXACT JSON structure: {"response": "your character response here", "emotion": "your emotional state"}
+- Reply in this EXACT JSON structure: {"response": "your character response here", "emotion": "your emotional state", "intensity": 0.5}
 - Do NOT include any text before or after the JSON
-- Valid emotions: happy, sad, angry, nervous, confident, suspicious, worried, neutral, etc.
+- Valid emotions: %s
+- Intensity is how strongly you feel the emotion, from 0.0 (barely) to 1.0 (overwhelmingly)`,
+			c.Name, c.Name, c.Personality, reliabilityNote,
+			murder.Victim, murder.Location, murder.Weapon, murder.Killer, c.Knowledge,
+			c.secretsPrompt(murder), strings.Join(emotion.Names(), ", "))
 
-Detective's question: "%s"
+		messages = append(messages, &Message{Role: llm.RoleSystem, Content: scenario, Timestamp: time.Now()})
 
-Your JSON response as %s:`,
-			c.Name, c.Name, c.Personality, reliabilityNote,
-			murder.Location, murder.Weapon, murder.Killer, c.Knowledge,
-			question, c.Name)
+		latest = fmt.Sprintf("Detective's question: %s", question)
+	}
+
+	latest = c.statePrompt(turn, rules) + latest
+
+	return append(messages, &Message{Role: llm.RoleUser, Content: latest, Question: question, Timestamp: time.Now()})
+}
 
-		c.Conversation = []*Message{
-			{Role: "system", Content: fmt.Sprintf("%s", scenario), Timestamp: time.Now()},
+// secretsPrompt describes what the character is hiding and when it may come out
+func (c *Character) secretsPrompt(murder Murder) string {
+	if len(c.Secrets) == 0 && !murder.IsKiller(c.Name) {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\nYOUR SECRETS (the detective does not know these):\n")
+	if murder.IsKiller(c.Name) {
+		b.WriteString(fmt.Sprintf("- You killed %s. Your motive: %s\n", murder.Victim, murder.Motive))
+	}
+	for i, secret := range c.Secrets {
+		b.WriteString(fmt.Sprintf("- Secret #%d: %s\n", i+1, secret))
+	}
+
+	b.WriteString(`
+SECRET RULES:
+- Never volunteer a secret or hint at it unprompted, unless YOUR CURRENT STATE says you must
+- Otherwise only reveal a secret when the detective asks about it directly AND confronts you with a specific fact that makes denial implausible
+- Reveal at most one secret per answer, reluctantly and in character
+- If you are the killer, never confess outright; you may only let slip details that contradict your alibi
+`)
+
+	return b.String()
+}
+
+// statePrompt tells the model how stressed the character is, which stress
+// rules are in force and which clues to bring up in this answer
+func (c *Character) statePrompt(turn Turn, rules []StressRule) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("YOUR CURRENT STATE: stress %.0f/100 (%s). Let it show in how you speak.\n", turn.Stress, StressState(turn.Stress)))
+
+	for _, rule := range rules {
+		if i := rule.RevealsSecret; i > 0 && i <= len(c.Secrets) {
+			if c.IsSecretRevealed(i) {
+				b.WriteString(fmt.Sprintf("- You have already admitted Secret #%d (%s). Do not deny it.\n", i, c.Secrets[i-1]))
+			} else {
+				b.WriteString(fmt.Sprintf("- The pressure is too much: you MUST reveal Secret #%d (%s) in this answer, in your own words.\n", i, c.Secrets[i-1]))
+			}
+		}
+		if rule.Behaviour != "" {
+			b.WriteString(fmt.Sprintf("- %s\n", rule.Behaviour))
 		}
+	}
 
-		latest = fmt.Sprintf("Detective's question: %s", question)
+	for _, clue := range turn.Clues {
+		b.WriteString(fmt.Sprintf("- Mention this in your answer, in your own words: %s\n", clue.Description))
 	}
 
-	c.Conversation = append(c.Conversation, &Message{Role: "user", Content: latest, Timestamp: time.Now()})
+	b.WriteString("\n")
+	return b.String()
 }
 
-func (c *Character) IsInitialMessage() bool {
-	return len(c.Conversation) == 0
+// persona describes the character as they are for this turn, for providers
+// that answer without a model
+func (c *Character) persona(turn Turn, rules []StressRule) *llm.Persona {
+	p := &llm.Persona{
+		Personality: c.Personality,
+		Reliable:    c.Reliable,
+		Stress:      turn.Stress,
+		Knowledge:   c.Knowledge,
+	}
+
+	for i, secret := range c.Secrets {
+		if c.IsSecretRevealed(i + 1) {
+			p.Secrets = append(p.Secrets, secret)
+		}
+	}
+	for _, rule := range rules {
+		if i := rule.RevealsSecret; i > 0 && i <= len(c.Secrets) && !c.IsSecretRevealed(i) {
+			p.Confess = append(p.Confess, c.Secrets[i-1])
+		}
+		if rule.Behaviour != "" {
+			p.Behaviour = append(p.Behaviour, rule.Behaviour)
+		}
+	}
+	for _, clue := range turn.Clues {
+		p.Mention = append(p.Mention, clue.Description)
+	}
+
+	return p
 }
 
-func (c *Character) serialiseConversation() string {
-	s, err := json.Marshal(c.Conversation)
-	if err != nil {
-		logger.New().Error(err.Error())
-		return ""
+// recordRevealedSecrets marks the secrets the active rules force out, once
+// the answer has actually told them. A secret the answer kept back is asked
+// for again next time.
+func (c *Character) recordRevealedSecrets(rules []StressRule, answer string) {
+	for _, rule := range rules {
+		i := rule.RevealsSecret
+		if i > 0 && i <= len(c.Secrets) && !c.IsSecretRevealed(i) && tellsSecret(answer, c.Secrets[i-1]) {
+			c.RevealedSecrets = append(c.RevealedSecrets, i)
+		}
 	}
+}
 
-	return string(s)
+// secretFillers are words too common to show that an answer tells a secret
+var secretFillers = map[string]bool{
+	"about": true, "actually": true, "after": true, "been": true, "before": true,
+	"could": true, "from": true, "have": true, "having": true, "that": true,
+	"their": true, "them": true, "there": true, "they": true, "this": true,
+	"very": true, "were": true, "what": true, "when": true, "with": true, "would": true,
+}
+
+// tellsSecret reports whether answer has at least half of the telling words
+// of secret, so a secret told in the character's own words still counts. A
+// word counts when one of the answer is the same or shares its start, such
+// as "needs" and "need".
+func tellsSecret(answer, secret string) bool {
+	said := secretWords(answer)
+
+	var words, told int
+	for _, word := range secretWords(secret) {
+		words++
+		for _, other := range said {
+			if strings.HasPrefix(word, other) || strings.HasPrefix(other, word) {
+				told++
+				break
+			}
+		}
+	}
+	return words > 0 && told*2 >= words
 }
 
-// extractJSONFromResponse tries to find and extract JSON from a text response
-func (c *Character) extractJSONFromResponse(response string) (*llm.CharacterReply, error) {
-	// Look for JSON patterns in the response
-	start := strings.Index(response, "{")
-	end := strings.LastIndex(response, "}")
+// secretWords returns the lowercase words of text with four letters or
+// more, leaving out fillers
+func secretWords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) >= 4 && !secretFillers[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
 
-	if start != -1 && end != -1 && end > start {
-		jsonStr := response[start : end+1]
-		var reply llm.CharacterReply
-		if err := json.Unmarshal([]byte(jsonStr), &reply); err == nil {
-			return &reply, nil
+// IsSecretRevealed reports whether secret i (1-based) has been revealed
+func (c *Character) IsSecretRevealed(i int) bool {
+	for _, revealed := range c.RevealedSecrets {
+		if revealed == i {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil, fmt.Errorf("no valid JSON found in response")
+func (c *Character) IsInitialMessage() bool {
+	return len(c.Conversation) == 0
+}
+
+// chatMessages returns the conversation followed by pending as role-tagged
+// chat messages
+func (c *Character) chatMessages(pending []*Message) []llm.ChatMessage {
+	messages := make([]llm.ChatMessage, 0, len(c.Conversation)+len(pending))
+	for _, msg := range append(c.Conversation[:len(c.Conversation):len(c.Conversation)], pending...) {
+		messages = append(messages, llm.ChatMessage{Role: msg.Role, Content: msg.Content})
+	}
+	return messages
 }
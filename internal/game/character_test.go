@@ -0,0 +1,140 @@
+package game
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm"
+	"github.com/tahcohcat/gofigure-web/internal/llm/replay"
+)
+
+const testScript = `
+characters:
+  - name: "Captain Rodriguez"
+    replies:
+      - pattern: "budget|money"
+        response: "Fine. The line cut my budget and I was furious with the chef about it."
+        emotion: "nervous"
+        intensity: 0.8
+      - pattern: "freezer"
+        response: "The freezer only locks from the outside."
+        emotion: "confident"
+      - pattern: "silence"
+        response: "I have nothing more to say about that."
+        emotion: "defensive"
+`
+
+// scriptClient returns a replay client that answers from testScript
+func scriptClient(t *testing.T) llm.LLM {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "script.yaml")
+	if err := os.WriteFile(path, []byte(testScript), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	client, err := replay.NewClient(&config.ReplayConfig{Mode: string(replay.ModeScript), Script: path}, nil)
+	if err != nil {
+		t.Fatalf("failed to create replay client: %v", err)
+	}
+	return client
+}
+
+func testCharacter() *Character {
+	return &Character{
+		Name:        "Captain Rodriguez",
+		Personality: "Stern and proud",
+		Knowledge:   []string{"The freezer locks from the outside"},
+		Reliable:    true,
+		Secrets:     []string{"Furious about the budget cut"},
+		Stress: StressProfile{Rules: []StressRule{
+			{Above: 70, RevealsSecret: 1},
+		}},
+	}
+}
+
+var testMurder = Murder{
+	Title:    "Death on the Aurora Star",
+	Victim:   "Chef Laurent",
+	Killer:   "Captain Rodriguez",
+	Weapon:   "Meat cleaver",
+	Location: "Galley freezer",
+}
+
+func TestAskQuestionScript(t *testing.T) {
+	client := scriptClient(t)
+	c := testCharacter()
+
+	reply, err := c.AskQuestion(context.Background(), "Who can lock the freezer?", testMurder, Turn{Stress: 20}, client)
+	if err != nil {
+		t.Fatalf("AskQuestion: %v", err)
+	}
+	if reply.Response != "The freezer only locks from the outside." || reply.Emotion != "confident" {
+		t.Errorf("got %q (%s)", reply.Response, reply.Emotion)
+	}
+	if reply.Model != "replay/script" {
+		t.Errorf("got model %q, want replay/script", reply.Model)
+	}
+
+	// The opening question brings the scenario with it
+	if len(c.Conversation) != 3 {
+		t.Fatalf("got %d messages after the first answer, want 3", len(c.Conversation))
+	}
+	roles := []string{llm.RoleSystem, llm.RoleUser, llm.RoleAssistant}
+	for i, msg := range c.Conversation {
+		if msg.Role != roles[i] {
+			t.Errorf("message %d has role %s, want %s", i, msg.Role, roles[i])
+		}
+	}
+	if c.Conversation[1].Question != "Who can lock the freezer?" {
+		t.Errorf("got question %q", c.Conversation[1].Question)
+	}
+
+	if _, err := c.AskQuestion(context.Background(), "Anything about the freezer?", testMurder, Turn{Stress: 25}, client); err != nil {
+		t.Fatalf("AskQuestion: %v", err)
+	}
+	if len(c.Conversation) != 5 {
+		t.Errorf("got %d messages after the second answer, want 5", len(c.Conversation))
+	}
+}
+
+func TestAskQuestionUnanswered(t *testing.T) {
+	client := scriptClient(t)
+	c := testCharacter()
+
+	// The script has no reply and no default for this
+	if _, err := c.AskQuestion(context.Background(), "How was dinner?", testMurder, Turn{Stress: 20}, client); err == nil {
+		t.Fatal("expected an error when the script has no reply")
+	}
+	if len(c.Conversation) != 0 {
+		t.Errorf("an unanswered question joined the conversation: %d messages", len(c.Conversation))
+	}
+}
+
+func TestAskQuestionRevealsSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		stress   float64
+		revealed bool
+	}{
+		{"told under pressure", "What about the budget?", 80, true},
+		{"kept back under pressure", "Break your silence!", 80, false},
+		{"told without pressure", "What about the budget?", 30, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testCharacter()
+			if _, err := c.AskQuestion(context.Background(), tt.question, testMurder, Turn{Stress: tt.stress}, scriptClient(t)); err != nil {
+				t.Fatalf("AskQuestion: %v", err)
+			}
+			if got := c.IsSecretRevealed(1); got != tt.revealed {
+				t.Errorf("secret revealed: got %v, want %v", got, tt.revealed)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"strings"
+	"time"
+)
+
+// Ways a clue can be discovered
+const (
+	ClueSourceQuestion = "question"
+	ClueSourceStress   = "stress"
+	ClueSourceSearch   = "search"
+)
+
+// Clue is a piece of evidence the detective can uncover during a game
+type Clue struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Trigger     ClueTrigger `json:"trigger"`
+}
+
+// ClueTrigger describes how a clue is discovered. A clue with a character
+// and topics is found by asking that character about any of the topics; a
+// clue with a character and stress_above is found once the character's
+// stress is above the threshold (both must hold when both are set). A clue
+// with a location is found by searching that location.
+type ClueTrigger struct {
+	Character   string   `json:"character,omitempty"`
+	Topics      []string `json:"topics,omitempty"`
+	StressAbove float64  `json:"stress_above,omitempty"`
+	Location    string   `json:"location,omitempty"`
+}
+
+// DiscoveredClue is a clue the detective has found, and how
+type DiscoveredClue struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	Character   string `json:"character,omitempty"`
+	Location    string `json:"location,omitempty"`
+	GameTime    int    `json:"game_time"` // seconds of game time elapsed
+}
+
+// ClueTracker keeps track of the clues discovered in one game session
+type ClueTracker struct {
+	clues      []Clue
+	discovered map[string]DiscoveredClue
+	order      []string
+}
+
+// NewClueTracker starts a game with none of murder's clues discovered
+func NewClueTracker(murder *Murder) *ClueTracker {
+	return &ClueTracker{
+		clues:      murder.Clues,
+		discovered: make(map[string]DiscoveredClue),
+	}
+}
+
+// RestoreClueTracker rebuilds the tracker of a saved game from the clues
+// discovered so far. Clues no longer in the mystery are kept as discovered.
+func RestoreClueTracker(murder *Murder, discovered []DiscoveredClue) *ClueTracker {
+	t := NewClueTracker(murder)
+	for _, d := range discovered {
+		if t.IsDiscovered(d.ID) {
+			continue
+		}
+		t.discovered[d.ID] = d
+		t.order = append(t.order, d.ID)
+	}
+	return t
+}
+
+// FromQuestion returns the undiscovered clues that asking character question
+// at the given stress level uncovers
+func (t *ClueTracker) FromQuestion(character, question string, stress float64) []Clue {
+	questionLower := strings.ToLower(question)
+
+	var found []Clue
+	for _, clue := range t.clues {
+		trigger := clue.Trigger
+		if t.IsDiscovered(clue.ID) || trigger.Character != character {
+			continue
+		}
+		if len(trigger.Topics) == 0 && trigger.StressAbove <= 0 {
+			continue
+		}
+		if trigger.StressAbove > 0 && stress <= trigger.StressAbove {
+			continue
+		}
+		if len(trigger.Topics) > 0 && !mentionsAny(questionLower, trigger.Topics) {
+			continue
+		}
+		found = append(found, clue)
+	}
+	return found
+}
+
+// AtLocation returns the undiscovered clues hidden at location
+func (t *ClueTracker) AtLocation(location string) []Clue {
+	var found []Clue
+	for _, clue := range t.clues {
+		if !t.IsDiscovered(clue.ID) && clue.Trigger.Location != "" && strings.EqualFold(clue.Trigger.Location, location) {
+			found = append(found, clue)
+		}
+	}
+	return found
+}
+
+// Discover marks clue as found at the given point in game time
+func (t *ClueTracker) Discover(clue Clue, source string, gameTime time.Duration) DiscoveredClue {
+	if d, ok := t.discovered[clue.ID]; ok {
+		return d
+	}
+
+	d := DiscoveredClue{
+		ID:          clue.ID,
+		Name:        clue.Name,
+		Description: clue.Description,
+		Source:      source,
+		Location:    clue.Trigger.Location,
+		GameTime:    int(gameTime.Seconds()),
+	}
+	if source != ClueSourceSearch {
+		d.Character = clue.Trigger.Character
+		d.Location = ""
+	}
+
+	t.discovered[clue.ID] = d
+	t.order = append(t.order, clue.ID)
+	return d
+}
+
+// IsDiscovered reports whether the clue with the given id has been found
+func (t *ClueTracker) IsDiscovered(id string) bool {
+	_, ok := t.discovered[id]
+	return ok
+}
+
+// Discovered returns the clues found so far, in the order they were found
+func (t *ClueTracker) Discovered() []DiscoveredClue {
+	clues := make([]DiscoveredClue, 0, len(t.order))
+	for _, id := range t.order {
+		clues = append(clues, t.discovered[id])
+	}
+	return clues
+}
+
+// Missed returns the clues that have not been found
+func (t *ClueTracker) Missed() []Clue {
+	var missed []Clue
+	for _, clue := range t.clues {
+		if !t.IsDiscovered(clue.ID) {
+			missed = append(missed, clue)
+		}
+	}
+	return missed
+}
+
+// Total returns the number of clues in the mystery
+func (t *ClueTracker) Total() int {
+	return len(t.clues)
+}
+
+// QuestionSource names how a clue uncovered by FromQuestion was found
+func QuestionSource(clue Clue) string {
+	if len(clue.Trigger.Topics) == 0 {
+		return ClueSourceStress
+	}
+	return ClueSourceQuestion
+}
+
+func mentionsAny(text string, topics []string) bool {
+	for _, topic := range topics {
+		if topic != "" && strings.Contains(text, strings.ToLower(topic)) {
+			return true
+		}
+	}
+	return false
+}
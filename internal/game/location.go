@@ -0,0 +1,75 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
+)
+
+// Room is a place the detective can search. Hidden items are clues whose
+// trigger names the room as their location.
+type Room struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Items       []string `json:"items,omitempty"` // unremarkable things the narrator can mention
+}
+
+// Room returns the room with the given name, ignoring case
+func (m *Murder) Room(name string) (*Room, bool) {
+	for i := range m.Rooms {
+		if strings.EqualFold(m.Rooms[i].Name, strings.TrimSpace(name)) {
+			return &m.Rooms[i], true
+		}
+	}
+	return nil, false
+}
+
+// searchPrompt asks the narrator to describe a search of room
+func searchPrompt(murder Murder, room *Room, found []Clue) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`You are the narrator of a murder mystery called "%s". %s has been murdered.
+
+The detective searches the %s.
+Room: %s
+`, murder.Title, murder.Victim, room.Name, room.Description))
+
+	if len(room.Items) > 0 {
+		b.WriteString(fmt.Sprintf("Things in the room: %s\n", strings.Join(room.Items, ", ")))
+	}
+
+	if len(found) == 0 {
+		b.WriteString("The detective finds nothing of importance.\n")
+	} else {
+		b.WriteString("The detective finds:\n")
+		for _, clue := range found {
+			b.WriteString(fmt.Sprintf("- %s: %s\n", clue.Name, clue.Description))
+		}
+	}
+
+	b.WriteString(`
+INSTRUCTIONS:
+- Describe the search in 2 to 4 atmospheric sentences, speaking to the detective as "you"
+- Mention every finding listed above and do not invent any other evidence
+- Never reveal who the killer is
+- You MUST respond in this exact JSON format: {"response": "your narration here", "emotion": "the mood of the scene", "intensity": 0.5}
+- The mood must be one of: ` + strings.Join(emotion.Names(), ", "))
+
+	return b.String()
+}
+
+// SearchNarration describes a search without the help of a model
+func SearchNarration(room *Room, found []Clue) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("You search the %s. %s", room.Name, room.Description))
+
+	if len(found) == 0 {
+		b.WriteString(" Nothing here seems out of place.")
+		return b.String()
+	}
+
+	for _, clue := range found {
+		b.WriteString(fmt.Sprintf(" You find something: %s. %s.", strings.ToLower(clue.Name), strings.TrimSuffix(clue.Description, ".")))
+	}
+	return b.String()
+}
@@ -4,15 +4,46 @@ import (
 	"github.com/schollz/closestmatch"
 )
 
+// Credit attributes an asset (usually a character sprite) used by a mystery
+type Credit struct {
+	ImagePath  string `json:"image_path"`
+	CreditHTML string `json:"credit_html"`
+}
+
 // Murder scenario loaded from JSON
 type Murder struct {
+	ID          string      `json:"-"` // the id of the mystery in the registry, set when it is loaded from there
 	Title       string      `json:"title"`
+	Difficulty  string      `json:"difficulty"`
+	Description string      `json:"description"`
+	Victim      string      `json:"victim"`
 	Killer      string      `json:"killer"`
 	Weapon      string      `json:"weapon"`
 	Location    string      `json:"location"`
+	Motive      string      `json:"motive"`
 	Intro       string      `json:"introduction"`
 	NarratorTTS []TTS       `json:"narrator_tts,omitempty"`
 	Characters  []Character `json:"characters"`
+	Clues       []Clue      `json:"clues,omitempty"`
+	Rooms       []Room      `json:"rooms,omitempty"`
+	Credits     []Credit    `json:"credits,omitempty"`
+}
+
+// IsKiller reports whether the named character is the murderer
+func (m *Murder) IsKiller(name string) bool {
+	return name == m.Killer
+}
+
+// clone returns a copy of the mystery whose characters can be interrogated
+// without touching the original
+func (m Murder) clone() Murder {
+	c := m
+	c.Characters = make([]Character, len(m.Characters))
+	copy(c.Characters, m.Characters)
+	for i := range c.Characters {
+		c.Characters[i].Conversation = nil
+	}
+	return c
 }
 
 func (m *Murder) closesCharacterMatches() *closestmatch.ClosestMatch {
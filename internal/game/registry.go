@@ -0,0 +1,238 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/tahcohcat/gofigure-web/internal/logger"
+	"github.com/tahcohcat/gofigure-web/internal/validator"
+)
+
+// ErrUnknownMystery is returned when a mystery id is not in the registry
+var ErrUnknownMystery = errors.New("unknown mystery")
+
+// MysterySummary is the index entry shown in the mystery selection screen
+type MysterySummary struct {
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Difficulty     string `json:"difficulty"`
+	Description    string `json:"description"`
+	CharacterCount int    `json:"character_count"`
+}
+
+type registryEntry struct {
+	summary MysterySummary
+	murder  Murder
+}
+
+// MysteryRegistry indexes every mystery file in a directory. The id of a
+// mystery is its file name without the .json extension.
+type MysteryRegistry struct {
+	dir       string
+	validator *validator.Validator
+	mu        sync.RWMutex
+	entries   map[string]registryEntry
+	watcher   *fsnotify.Watcher
+	logger    *logger.Log
+}
+
+// NewMysteryRegistry scans dir and returns a registry of the mysteries found.
+// When v is not nil, mysteries that fail validation are left out.
+func NewMysteryRegistry(dir string, v *validator.Validator) (*MysteryRegistry, error) {
+	r := &MysteryRegistry{
+		dir:       dir,
+		validator: v,
+		entries:   make(map[string]registryEntry),
+		logger:    logger.New(),
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload rescans the mysteries directory. Files that fail to load are
+// skipped with a warning so one broken mystery doesn't hide the others.
+func (r *MysteryRegistry) Reload() error {
+	files, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read mysteries directory %s: %w", r.dir, err)
+	}
+
+	entries := make(map[string]registryEntry)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		id := strings.TrimSuffix(file.Name(), ".json")
+		path := filepath.Join(r.dir, file.Name())
+
+		if r.validator != nil {
+			issues, err := r.validator.ValidateFile(path)
+			if err == nil && len(issues) > 0 {
+				err = &validator.Error{Issues: issues}
+			}
+			if err != nil {
+				r.logger.WithError(err).Warn(fmt.Sprintf("skipping invalid mystery %s", id))
+				continue
+			}
+		}
+
+		murder, err := LoadMurderFromFile(path)
+		if err != nil {
+			r.logger.WithError(err).Warn(fmt.Sprintf("skipping mystery %s", id))
+			continue
+		}
+
+		entries[id] = registryEntry{
+			summary: MysterySummary{
+				ID:             id,
+				Title:          murder.Title,
+				Difficulty:     murder.Difficulty,
+				Description:    murder.Description,
+				CharacterCount: len(murder.Characters),
+			},
+			murder: murder,
+		}
+	}
+
+	r.mu.Lock()
+	r.entries = entries
+	r.mu.Unlock()
+
+	r.logger.Info(fmt.Sprintf("Loaded %d mysteries from %s", len(entries), r.dir))
+	return nil
+}
+
+// Watch reloads the registry whenever a file in the mysteries directory changes
+func (r *MysteryRegistry) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create mysteries watcher: %w", err)
+	}
+
+	if err := watcher.Add(r.dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s: %w", r.dir, err)
+	}
+
+	r.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !strings.HasSuffix(event.Name, ".json") || event.Has(fsnotify.Chmod) {
+					continue
+				}
+				if err := r.Reload(); err != nil {
+					r.logger.WithError(err).Warn("failed to reload mysteries")
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				r.logger.WithError(err).Warn("mysteries watcher error")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops watching the mysteries directory
+func (r *MysteryRegistry) Close() error {
+	if r.watcher != nil {
+		return r.watcher.Close()
+	}
+	return nil
+}
+
+// List returns the summaries of all mysteries, easiest first
+func (r *MysteryRegistry) List() []MysterySummary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summaries := make([]MysterySummary, 0, len(r.entries))
+	for _, entry := range r.entries {
+		summaries = append(summaries, entry.summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		di, dj := difficultyRank(summaries[i].Difficulty), difficultyRank(summaries[j].Difficulty)
+		if di != dj {
+			return di < dj
+		}
+		return summaries[i].Title < summaries[j].Title
+	})
+
+	return summaries
+}
+
+// Count returns the number of playable mysteries
+func (r *MysteryRegistry) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.entries)
+}
+
+// Models returns the models that characters of the mysteries ask for
+// instead of the configured one, sorted and without repeats
+func (r *MysteryRegistry) Models() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var models []string
+	for _, entry := range r.entries {
+		for _, char := range entry.murder.Characters {
+			if model := char.LLM.Model; model != "" && !seen[model] {
+				seen[model] = true
+				models = append(models, model)
+			}
+		}
+	}
+
+	sort.Strings(models)
+	return models
+}
+
+// Load returns a fresh copy of the mystery with the given id, ready for a new game
+func (r *MysteryRegistry) Load(id string) (Murder, error) {
+	r.mu.RLock()
+	entry, ok := r.entries[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return Murder{}, fmt.Errorf("%w: %s", ErrUnknownMystery, id)
+	}
+
+	murder := entry.murder.clone()
+	murder.ID = id
+	return murder, nil
+}
+
+func difficultyRank(difficulty string) int {
+	switch strings.ToLower(difficulty) {
+	case "easy":
+		return 0
+	case "medium":
+		return 1
+	case "hard":
+		return 2
+	default:
+		return 3
+	}
+}
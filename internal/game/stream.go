@@ -0,0 +1,115 @@
+package game
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// responseStream picks the text of the "response" field out of a JSON reply
+// while it is still being generated, so it can be shown as it arrives.
+// Nothing is passed on before the response starts, so a code fence or a
+// preamble around the JSON never reaches the player; a reply that isn't JSON
+// at all only arrives as the whole reply.
+type responseStream struct {
+	onText func(text string) error
+	raw    strings.Builder
+	sent   int // bytes of the decoded response already passed to onText
+}
+
+func newResponseStream(onText func(text string) error) *responseStream {
+	return &responseStream{onText: onText}
+}
+
+// write takes the next token of the reply and passes on any new response text
+func (s *responseStream) write(token string) error {
+	s.raw.WriteString(token)
+
+	text, ok := partialResponse(s.raw.String())
+	if !ok || len(text) <= s.sent {
+		return nil
+	}
+
+	next := text[s.sent:]
+	s.sent = len(text)
+	return s.onText(next)
+}
+
+// partialResponse decodes as much of the response field of raw as has been
+// generated so far. Anything before the JSON object, such as a code fence or
+// a sentence introducing the reply, is skipped. ok is false until the value
+// of the response field has started.
+func partialResponse(raw string) (text string, ok bool) {
+	object := strings.Index(raw, "{")
+	if object == -1 {
+		return "", false
+	}
+	raw = raw[object:]
+
+	key := strings.Index(raw, `"response"`)
+	if key == -1 {
+		return "", false
+	}
+
+	rest := strings.TrimLeft(raw[key+len(`"response"`):], " \t\r\n")
+	if !strings.HasPrefix(rest, ":") {
+		return "", false
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	if !strings.HasPrefix(rest, `"`) {
+		return "", false
+	}
+	value := rest[1:]
+
+	// Find where the string ends, or the last point before a half-received
+	// escape sequence
+	end := len(value)
+	for i := 0; i < len(value); i++ {
+		if value[i] == '"' {
+			end = i
+			break
+		}
+		if value[i] != '\\' {
+			continue
+		}
+
+		size := 2
+		if i+1 < len(value) && value[i+1] == 'u' {
+			size = 6
+			// A high surrogate is only complete with the low one after it
+			if i+6 <= len(value) && strings.ContainsAny(value[i+2:i+3], "dD") && strings.ContainsAny(value[i+3:i+4], "89abAB") {
+				size = 12
+			}
+		}
+		if i+size > len(value) {
+			end = i
+			break
+		}
+		i += size - 1
+	}
+
+	// Nor show half of a character that is still arriving
+	end = wholeRunes(value[:end])
+
+	if err := json.Unmarshal([]byte(`"`+value[:end]+`"`), &text); err != nil {
+		return "", false
+	}
+	return text, true
+}
+
+// wholeRunes returns the length of s without a partly received UTF-8
+// character at the end
+func wholeRunes(s string) int {
+	if s == "" {
+		return 0
+	}
+
+	start := len(s) - 1
+	for start > 0 && len(s)-start < utf8.UTFMax && !utf8.RuneStart(s[start]) {
+		start--
+	}
+	if !utf8.FullRuneInString(s[start:]) {
+		return start
+	}
+	return len(s)
+}
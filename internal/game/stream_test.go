@@ -0,0 +1,73 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPartialResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		text string
+		ok   bool
+	}{
+		{"nothing yet", "", "", false},
+		{"object before the response", `{"emotion":"nervous",`, "", false},
+		{"key before its value", `{"response":`, "", false},
+		{"value started", `{"response":"I was`, "I was", true},
+		{"whole reply", `{"response":"I was on deck.","emotion":"calm"}`, "I was on deck.", true},
+		{"escapes", `{"response":"He said \"no\"\nthen left`, "He said \"no\"\nthen left", true},
+		{"half an escape", `{"response":"He said \`, "He said ", true},
+		{"half a unicode escape", `{"response":"caf\u00`, "caf", true},
+		{"code fence", "```json\n{\"response\":\"I was", "I was", true},
+		{"code fence alone", "```json\n", "", false},
+		{"preamble", `Here is my reply: {"response":"I was`, "I was", true},
+		{"preamble naming the field", `Here is the "response": {"response":"I was`, "I was", true},
+		{"preamble alone", "Sure! Here is", "", false},
+		{"plain text", "I was on deck all night.", "", false},
+		{"half a character", "{\"response\":\"caf\xc3", "caf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := partialResponse(tt.raw)
+			if text != tt.text || ok != tt.ok {
+				t.Errorf("got %q, %v, want %q, %v", text, ok, tt.text, tt.ok)
+			}
+		})
+	}
+}
+
+func TestResponseStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   string
+	}{
+		{"split key", []string{`{"res`, `ponse`, `": "I was`, ` on deck`, `.","emotion":"calm"}`}, "I was on deck."},
+		{"split escape", []string{`{"response":"Say \`, `"hi\`, `""}`}, `Say "hi"`},
+		{"split character", []string{"{\"response\":\"caf\xc3", "\xa9 au lait\"}"}, "café au lait"},
+		{"fenced", []string{"```", "json\n{", `"response":"Yes`, `."}`, "\n```"}, "Yes."},
+		{"preamble", []string{"Of course. ", "Here it is:\n", `{"response":"No`, `."}`}, "No."},
+		{"plain text", []string{"I was ", "on deck."}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var shown strings.Builder
+			stream := newResponseStream(func(text string) error {
+				shown.WriteString(text)
+				return nil
+			})
+			for _, token := range tt.tokens {
+				if err := stream.write(token); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			if shown.String() != tt.want {
+				t.Errorf("showed %q, want %q", shown.String(), tt.want)
+			}
+		})
+	}
+}
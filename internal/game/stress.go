@@ -0,0 +1,312 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	baseQuestionStress = 5.0
+	triggerStress      = 12.0
+	maxStress          = 100.0
+	stressJitter       = 10.0 // total spread of the random component, ±5
+)
+
+// StressProfile tunes how a character reacts to interrogation. All fields
+// are optional; the zero value behaves like an average witness.
+type StressProfile struct {
+	Baseline       float64      `json:"baseline,omitempty"`         // resting stress level, 0-100
+	Sensitivity    float64      `json:"sensitivity,omitempty"`      // multiplier on question pressure, 1.0 when unset
+	DecayPerMinute float64      `json:"decay_per_minute,omitempty"` // stress shed per minute of game time, 2.0 when unset
+	Triggers       []string     `json:"triggers,omitempty"`         // topics that rattle this character in particular
+	Rules          []StressRule `json:"rules,omitempty"`            // behaviour that kicks in above a stress threshold
+}
+
+// StressRule changes what a character says once their stress is above a
+// threshold, e.g. {"above": 70, "reveals_secret": 2} or
+// {"above": 90, "behaviour": "Refuse to answer until the detective calms you down"}
+type StressRule struct {
+	Above         float64 `json:"above"`
+	RevealsSecret int     `json:"reveals_secret,omitempty"` // 1-based index into the character's secrets
+	Behaviour     string  `json:"behaviour,omitempty"`      // instruction added to the prompt while the rule is active
+}
+
+// ActiveRules returns the rules whose threshold the given stress level is above
+func (p StressProfile) ActiveRules(level float64) []StressRule {
+	var active []StressRule
+	for _, rule := range p.Rules {
+		if level > rule.Above {
+			active = append(active, rule)
+		}
+	}
+	return active
+}
+
+func (p StressProfile) sensitivity() float64 {
+	if p.Sensitivity <= 0 {
+		return 1.0
+	}
+	return p.Sensitivity
+}
+
+func (p StressProfile) decayPerMinute() float64 {
+	if p.DecayPerMinute <= 0 {
+		return 2.0
+	}
+	return p.DecayPerMinute
+}
+
+// StressSample is one point in a character's stress history
+type StressSample struct {
+	GameTime int     `json:"game_time"` // seconds of game time elapsed
+	Level    float64 `json:"level"`
+	Change   float64 `json:"change"`
+	Question string  `json:"question,omitempty"`
+}
+
+// CharacterStress is the server-side stress state of one character
+type CharacterStress struct {
+	Level    float64        `json:"level"`
+	State    string         `json:"state"`
+	History  []StressSample `json:"history"`
+	profile  StressProfile
+	lastTime time.Duration
+}
+
+// StressReading is the result of a question on a character's stress
+type StressReading struct {
+	Level  float64
+	Change float64
+	State  string
+}
+
+// StressTracker owns the stress of every character in one game session.
+// Randomness comes from a per-session seed so a game can be reproduced.
+type StressTracker struct {
+	rng        *rand.Rand
+	draws      int // random numbers taken from rng, so a restored game carries on the same sequence
+	characters map[string]*CharacterStress
+}
+
+// NewStressTracker starts every character of murder at their baseline stress
+func NewStressTracker(murder *Murder, seed int64) *StressTracker {
+	t := &StressTracker{
+		rng:        rand.New(rand.NewSource(seed)),
+		characters: make(map[string]*CharacterStress, len(murder.Characters)),
+	}
+
+	for _, char := range murder.Characters {
+		baseline := clampStress(char.Stress.Baseline)
+		t.characters[char.Name] = &CharacterStress{
+			Level:   baseline,
+			State:   StressState(baseline),
+			History: []StressSample{{GameTime: 0, Level: baseline}},
+			profile: char.Stress,
+		}
+	}
+
+	return t
+}
+
+// Question works out the pressure of question on the named character at the
+// given point in game time. The reading is what the character's stress will
+// be once they have answered; it only takes effect with Apply, so a question
+// that goes unanswered leaves their stress as it was.
+func (t *StressTracker) Question(name, question string, gameTime time.Duration) StressReading {
+	cs, ok := t.characters[name]
+	if !ok {
+		return StressReading{State: StressState(0)}
+	}
+
+	cs.decay(gameTime)
+
+	increase := questionPressure(question, cs.profile) * cs.profile.sensitivity()
+	increase += (t.rng.Float64() - 0.5) * stressJitter
+	t.draws++
+
+	level := clampStress(math.Max(cs.profile.Baseline, cs.Level+increase))
+	return StressReading{Level: level, Change: level - cs.Level, State: StressState(level)}
+}
+
+// Apply puts a reading from Question into effect once the character has
+// answered, and returns the reading as applied. The change is added to the
+// stress the character has now, which other questions may have moved since.
+func (t *StressTracker) Apply(name, question string, reading StressReading, gameTime time.Duration) StressReading {
+	cs, ok := t.characters[name]
+	if !ok {
+		return reading
+	}
+
+	cs.decay(gameTime)
+
+	previous := cs.Level
+	cs.Level = clampStress(math.Max(cs.profile.Baseline, cs.Level+reading.Change))
+	cs.State = StressState(cs.Level)
+
+	change := cs.Level - previous
+	cs.History = append(cs.History, StressSample{
+		GameTime: int(gameTime.Seconds()),
+		Level:    cs.Level,
+		Change:   change,
+		Question: question,
+	})
+
+	return StressReading{Level: cs.Level, Change: change, State: cs.State}
+}
+
+// SavedStress is the state of a StressTracker that is saved with a game
+type SavedStress struct {
+	Draws      int                             `json:"draws"`
+	Characters map[string]SavedCharacterStress `json:"characters"`
+}
+
+// SavedCharacterStress is the saved stress of one character
+type SavedCharacterStress struct {
+	Level    float64        `json:"level"`
+	History  []StressSample `json:"history"`
+	LastTime time.Duration  `json:"last_time"` // game time of the last update
+}
+
+// Save returns the state of the tracker
+func (t *StressTracker) Save() SavedStress {
+	saved := SavedStress{
+		Draws:      t.draws,
+		Characters: make(map[string]SavedCharacterStress, len(t.characters)),
+	}
+	for name, cs := range t.characters {
+		saved.Characters[name] = SavedCharacterStress{Level: cs.Level, History: cs.History, LastTime: cs.lastTime}
+	}
+	return saved
+}
+
+// RestoreStressTracker rebuilds the tracker of a saved game of murder that
+// was started with seed. Characters no longer in the mystery are ignored.
+func RestoreStressTracker(murder *Murder, seed int64, saved SavedStress) *StressTracker {
+	t := NewStressTracker(murder, seed)
+
+	for t.draws < saved.Draws {
+		t.rng.Float64()
+		t.draws++
+	}
+
+	for name, s := range saved.Characters {
+		cs, ok := t.characters[name]
+		if !ok {
+			continue
+		}
+		cs.Level = clampStress(s.Level)
+		cs.State = StressState(cs.Level)
+		cs.History = s.History
+		cs.lastTime = s.LastTime
+	}
+
+	return t
+}
+
+// Level returns the current stress of the named character after decay
+func (t *StressTracker) Level(name string, gameTime time.Duration) float64 {
+	cs, ok := t.characters[name]
+	if !ok {
+		return 0
+	}
+	cs.decay(gameTime)
+	return cs.Level
+}
+
+// Character returns the stress state of the named character after decay
+func (t *StressTracker) Character(name string, gameTime time.Duration) (*CharacterStress, bool) {
+	cs, ok := t.characters[name]
+	if !ok {
+		return nil, false
+	}
+	cs.decay(gameTime)
+	return cs, true
+}
+
+// decay lowers stress towards the baseline for the game time that passed
+// since the last update
+func (cs *CharacterStress) decay(gameTime time.Duration) {
+	elapsed := gameTime - cs.lastTime
+	if elapsed <= 0 {
+		return
+	}
+	cs.lastTime = gameTime
+
+	relief := elapsed.Minutes() * cs.profile.decayPerMinute()
+	cs.Level = math.Max(clampStress(cs.profile.Baseline), cs.Level-relief)
+	cs.State = StressState(cs.Level)
+}
+
+// questionPressure scores how confrontational a question is
+func questionPressure(question string, profile StressProfile) float64 {
+	questionLower := strings.ToLower(question)
+	pressure := baseQuestionStress
+
+	// High stress keywords
+	highStressKeywords := []string{
+		"murder", "kill", "weapon", "blood", "death", "guilty",
+		"lie", "alibi", "where were you", "motive", "why did you",
+	}
+
+	// Medium stress keywords
+	mediumStressKeywords := []string{
+		"suspicious", "secret", "hidden", "truth", "evidence",
+		"witness", "saw", "heard", "relationship", "money",
+	}
+
+	// Low stress keywords (calming topics)
+	lowStressKeywords := []string{
+		"weather", "family", "work", "hobby", "general",
+		"hello", "how are", "nice day", "background",
+	}
+
+	for _, keyword := range highStressKeywords {
+		if strings.Contains(questionLower, keyword) {
+			pressure += 15.0
+		}
+	}
+
+	for _, keyword := range mediumStressKeywords {
+		if strings.Contains(questionLower, keyword) {
+			pressure += 8.0
+		}
+	}
+
+	for _, trigger := range profile.Triggers {
+		if trigger != "" && strings.Contains(questionLower, strings.ToLower(trigger)) {
+			pressure += triggerStress
+		}
+	}
+
+	for _, keyword := range lowStressKeywords {
+		if strings.Contains(questionLower, keyword) {
+			pressure = math.Max(1.0, pressure-5.0)
+		}
+	}
+
+	return pressure
+}
+
+// StressState describes a stress level in words
+func StressState(level float64) string {
+	switch {
+	case level < 25:
+		return "calm"
+	case level < 40:
+		return "composed"
+	case level < 55:
+		return "nervous"
+	case level < 70:
+		return "agitated"
+	case level < 85:
+		return "stressed"
+	default:
+		return "breaking"
+	}
+}
+
+func clampStress(level float64) float64 {
+	return math.Max(0, math.Min(maxStress, level))
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/tahcohcat/gofigure-web/config"
 	llmpkg "github.com/tahcohcat/gofigure-web/internal/llm"
@@ -15,18 +17,97 @@ import (
 type WebEngine struct {
 	config *config.Config
 	logger *logger.Log
+	llm    llmpkg.LLM     // shared by every request, nil if it couldn't be created
+	health *llmpkg.Health // whether the model of llm is available
+	broken error          // why there is no llm
 }
 
-func NewWebEngine() (*WebEngine, error) {
+// NewWebEngine creates the engine from the configuration. models, if not
+// nil, returns the models characters ask for instead of the configured one,
+// which must be available too for games to be played.
+func NewWebEngine(models func() []string) (*WebEngine, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
+	return NewWebEngineFromConfig(cfg, models), nil
+}
 
-	return &WebEngine{
+// NewWebEngineFromConfig creates the engine from cfg rather than the
+// configuration file
+func NewWebEngineFromConfig(cfg *config.Config, models func() []string) *WebEngine {
+	e := &WebEngine{
 		config: cfg,
 		logger: logger.New(),
-	}, nil
+	}
+
+	// The server still starts without a client, so the readiness probe can
+	// say what is wrong, but games can't be played
+	llmClient, err := llmpkg.NewLLMClient(cfg)
+	if err != nil {
+		e.broken = fmt.Errorf("failed to create LLM client: %w", err)
+		e.logger.WithError(err).Error("Failed to create LLM client, games can't be played until it is configured")
+		return e
+	}
+	e.llm = llmClient
+
+	interval := time.Duration(cfg.LLM.HealthCheckSeconds) * time.Second
+	e.health = llmpkg.NewHealth(llmClient, models, interval)
+	if status := e.health.Status(); !status.Ready {
+		e.logger.Warn(fmt.Sprintf("model %s is not available, games can't be started until it is: %s", status.Model, status.Error))
+	}
+
+	return e
+}
+
+// ModelStatus reports whether the model is available, as of the last check
+func (e *WebEngine) ModelStatus() llmpkg.HealthStatus {
+	if e.broken != nil {
+		model := e.config.LLM.Provider
+		if len(e.config.LLM.Providers) > 0 {
+			model = strings.Join(e.config.LLM.Providers, ",")
+		}
+		return llmpkg.HealthStatus{Model: model, Error: e.broken.Error()}
+	}
+	return e.health.Status()
+}
+
+// ProviderStatus reports the circuit breaker of every provider when there is
+// a fallback chain, and nothing otherwise
+func (e *WebEngine) ProviderStatus() []llmpkg.ProviderStatus {
+	client := e.llm
+	if cache, ok := client.(*llmpkg.Cache); ok {
+		client = cache.Client()
+	}
+	if router, ok := client.(*llmpkg.Router); ok {
+		return router.Status()
+	}
+	return nil
+}
+
+// CacheStats reports how the reply cache is doing, or nil if replies aren't
+// cached
+func (e *WebEngine) CacheStats() *llmpkg.CacheStats {
+	if cache, ok := e.llm.(*llmpkg.Cache); ok {
+		stats := cache.Stats()
+		return &stats
+	}
+	return nil
+}
+
+// Close stops the model availability checks and closes the reply cache
+func (e *WebEngine) Close() {
+	if e.health != nil {
+		e.health.Close()
+	}
+	if cache, ok := e.llm.(*llmpkg.Cache); ok {
+		cache.Close()
+	}
+}
+
+// Config returns the configuration the engine was created with
+func (e *WebEngine) Config() *config.Config {
+	return e.config
 }
 
 // LoadMurderFromFile loads a murder mystery from a JSON file
@@ -46,19 +127,40 @@ func LoadMurderFromFile(filename string) (Murder, error) {
 	return murder, nil
 }
 
-// AskCharacterQuestion handles character interaction for the web interface
-func (e *WebEngine) AskCharacterQuestion(ctx context.Context, character *Character, question string, murder Murder) (*llmpkg.CharacterReply, error) {
-	// Create LLM client
-	llmClient, err := llmpkg.NewLLMClient(e.config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create LLM client: %w", err)
+// AskCharacterQuestion has the model answer a question prepared with
+// Character.Prepare. The answer is not added to the conversation until the
+// exchange is applied.
+func (e *WebEngine) AskCharacterQuestion(ctx context.Context, exchange *Exchange) (*llmpkg.CharacterReply, error) {
+	return e.AskCharacterQuestionStream(ctx, exchange, nil)
+}
+
+// AskCharacterQuestionStream is AskCharacterQuestion that passes the text of
+// the answer to onText as it is generated
+func (e *WebEngine) AskCharacterQuestionStream(ctx context.Context, exchange *Exchange, onText func(text string) error) (*llmpkg.CharacterReply, error) {
+	if e.broken != nil {
+		return nil, e.broken
 	}
 
-	// Use the character's AskQuestion method
-	reply, err := character.AskQuestion(ctx, question, murder, llmClient)
+	reply, err := exchange.Ask(ctx, e.llm, onText)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get character response: %w", err)
 	}
 
 	return reply, nil
-}
\ No newline at end of file
+}
+
+// DescribeSearch has the narrator describe what the detective finds in room
+func (e *WebEngine) DescribeSearch(ctx context.Context, murder Murder, room *Room, found []Clue) (*llmpkg.CharacterReply, error) {
+	if e.broken != nil {
+		return nil, e.broken
+	}
+
+	// What the narrator knows is what a provider without a model can say
+	narrator := &Character{Name: "Narrator", Reliable: true, Knowledge: []string{SearchNarration(room, found)}}
+	reply, err := narrator.GetCharacterResponse(ctx, searchPrompt(murder, room, found), e.llm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get narrator response: %w", err)
+	}
+
+	return reply, nil
+}
@@ -0,0 +1,284 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+	"github.com/tahcohcat/gofigure-web/internal/logger"
+)
+
+// Cache keeps the replies of a client in SQLite and serves them again for
+// the same request. Only the opening question put to a character, and
+// one-shot prompts, are cached: later turns depend on the whole
+// conversation and are rarely asked twice. Only valid replies are kept, and
+// only when the model that was asked is the one that answered. A zero TTL
+// keeps replies until they are pushed out by newer ones.
+type Cache struct {
+	client   LLM
+	db       *sqlx.DB
+	ttl      time.Duration
+	max      int
+	sampling map[string]config.SamplingConfig // base sampling settings by provider
+	logger   *logger.Log
+
+	hits, misses, skipped, stored atomic.Int64
+}
+
+// CacheStats counts how the cache has done since the server started
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Skipped int64   `json:"skipped"` // requests past the first turn, which are never cached
+	Stored  int64   `json:"stored"`
+	Entries int     `json:"entries"`
+	HitRate float64 `json:"hit_rate"` // hits out of hits and misses
+}
+
+const cacheTable = `
+	CREATE TABLE IF NOT EXISTS llm_cache (
+		key TEXT PRIMARY KEY,
+		model TEXT NOT NULL,
+		response TEXT NOT NULL,
+		hits INTEGER DEFAULT 0,
+		created_at INTEGER NOT NULL, -- unix seconds
+		expires_at INTEGER NOT NULL, -- 0 for never
+		used_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_llm_cache_used_at ON llm_cache(used_at);`
+
+// NewCache caches the replies of client in the SQLite file cfg names.
+// sampling holds the sampling settings of each provider by name, which are
+// part of what a reply is cached under.
+func NewCache(client LLM, cfg config.CacheConfig, sampling map[string]config.SamplingConfig) (*Cache, error) {
+	db, err := sqlx.Connect("sqlite3", cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open LLM cache: %w", err)
+	}
+	if _, err := db.Exec(cacheTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create LLM cache table: %w", err)
+	}
+
+	return &Cache{
+		client:   client,
+		db:       db,
+		ttl:      time.Duration(cfg.TTLMinutes) * time.Minute,
+		max:      cfg.MaxEntries,
+		sampling: sampling,
+		logger:   logger.New(),
+	}, nil
+}
+
+// GenerateResponse answers a one-shot prompt from the cache if it can
+func (c *Cache) GenerateResponse(ctx context.Context, req Request) (Response, error) {
+	return c.cached("generate", req, nil, func() (Response, error) {
+		return c.client.GenerateResponse(ctx, req)
+	})
+}
+
+// Chat answers the opening turn of a conversation from the cache if it can
+func (c *Cache) Chat(ctx context.Context, req Request) (Response, error) {
+	return c.cached("chat", req, nil, func() (Response, error) {
+		return c.client.Chat(ctx, req)
+	})
+}
+
+// ChatStream passes a cached reply to onToken at once, and streams any other
+func (c *Cache) ChatStream(ctx context.Context, req Request, onToken TokenFunc) (Response, error) {
+	return c.cached("chat", req, onToken, func() (Response, error) {
+		return ChatStream(ctx, c.client, req, onToken)
+	})
+}
+
+func (c *Cache) IsModelAvailable(ctx context.Context, opts Options) error {
+	return c.client.IsModelAvailable(ctx, opts)
+}
+
+func (c *Cache) ModelName(opts Options) string {
+	return ModelName(c.client, opts)
+}
+
+// Client returns the client whose replies are cached
+func (c *Cache) Client() LLM {
+	return c.client
+}
+
+// Stats reports the hits and misses of the cache and how many replies it holds
+func (c *Cache) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Skipped: c.skipped.Load(),
+		Stored:  c.stored.Load(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	if err := c.db.Get(&stats.Entries, `SELECT COUNT(*) FROM llm_cache WHERE expires_at = 0 OR expires_at > ?`, time.Now().Unix()); err != nil {
+		c.logger.WithError(err).Warn("Failed to count LLM cache entries")
+	}
+	return stats
+}
+
+// Close closes the cache database
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// cached serves a request from the cache, or makes it with call and keeps
+// the reply. A cached reply is passed to onToken, if there is one, as a
+// whole.
+func (c *Cache) cached(kind string, req Request, onToken TokenFunc, call func() (Response, error)) (Response, error) {
+	if !openingTurn(req.Messages) {
+		c.skipped.Add(1)
+		return call()
+	}
+
+	model := ModelName(c.client, req.Options)
+	key := c.key(kind, model, req)
+
+	if reply, ok := c.get(key); ok {
+		c.hits.Add(1)
+		if onToken != nil {
+			if err := onToken(reply); err != nil {
+				return Response{}, err
+			}
+		}
+		return Response{Text: reply, Model: model}, nil
+	}
+	c.misses.Add(1)
+
+	reply, err := call()
+	if err != nil {
+		return Response{}, err
+	}
+
+	// A reply from a fallback provider, or one that needs repairing, isn't
+	// what the model asked would have said
+	if reply.Model == model {
+		if _, err := ParseReply(reply.Text); err == nil {
+			c.put(key, model, reply.Text)
+		}
+	}
+	return reply, nil
+}
+
+// openingTurn reports whether messages are the start of a conversation,
+// before the character has said anything
+func openingTurn(messages []ChatMessage) bool {
+	for _, msg := range messages {
+		if msg.Role == RoleAssistant {
+			return false
+		}
+	}
+	return true
+}
+
+// key identifies a request by the model asked and its sampling settings,
+// and by what was asked. A question put to a character is identified by the
+// mystery, the character, the normalized question, what the answer must
+// bring up and how the stress rules that apply say to behave, not by the
+// prompt: that holds the character's exact stress, which is different in
+// every game. Any other request is identified by its normalized prompt.
+func (c *Cache) key(kind, model string, req Request) string {
+	provider := strings.SplitN(model, "/", 2)[0]
+	_, sampling := chat.Resolve(req.Options, "", c.sampling[provider])
+
+	type asked struct {
+		Mystery   string   `json:"mystery"`
+		Character string   `json:"character"`
+		Question  string   `json:"question"`
+		Confess   []string `json:"confess,omitempty"`
+		Mention   []string `json:"mention,omitempty"`
+		Behaviour []string `json:"behaviour,omitempty"`
+	}
+
+	var normalized []ChatMessage
+	var question *asked
+	if q := req.Question; q != nil && q.Text != "" {
+		question = &asked{Mystery: q.Mystery, Character: q.Character, Question: chat.Normalize(q.Text)}
+		if q.Persona != nil {
+			question.Confess, question.Mention, question.Behaviour = q.Persona.Confess, q.Persona.Mention, q.Persona.Behaviour
+		}
+	} else {
+		normalized = make([]ChatMessage, len(req.Messages))
+		for i, msg := range req.Messages {
+			normalized[i] = ChatMessage{Role: msg.Role, Content: chat.Normalize(msg.Content)}
+		}
+	}
+
+	data, _ := json.Marshal(struct {
+		Kind     string                `json:"kind"`
+		Model    string                `json:"model"`
+		Sampling config.SamplingConfig `json:"sampling"`
+		Question *asked                `json:"question,omitempty"`
+		Messages []ChatMessage         `json:"messages,omitempty"`
+	}{kind, model, sampling, question, normalized})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) get(key string) (string, bool) {
+	now := time.Now().Unix()
+
+	var response string
+	err := c.db.Get(&response, `SELECT response FROM llm_cache WHERE key = ? AND (expires_at = 0 OR expires_at > ?)`, key, now)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			c.logger.WithError(err).Warn("Failed to read LLM cache")
+		}
+		return "", false
+	}
+
+	if _, err := c.db.Exec(`UPDATE llm_cache SET hits = hits + 1, used_at = ? WHERE key = ?`, now, key); err != nil {
+		c.logger.WithError(err).Warn("Failed to update LLM cache")
+	}
+	return response, true
+}
+
+// put keeps a reply, then drops expired replies and the least recently used
+// ones beyond the size limit
+func (c *Cache) put(key, model, response string) {
+	now := time.Now()
+
+	var expires int64 // never
+	if c.ttl > 0 {
+		expires = now.Add(c.ttl).Unix()
+	}
+
+	_, err := c.db.Exec(`
+		INSERT OR REPLACE INTO llm_cache (key, model, response, hits, created_at, expires_at, used_at)
+		VALUES (?, ?, ?, 0, ?, ?, ?)`,
+		key, model, response, now.Unix(), expires, now.Unix())
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to write LLM cache")
+		return
+	}
+	c.stored.Add(1)
+
+	if _, err := c.db.Exec(`DELETE FROM llm_cache WHERE expires_at > 0 AND expires_at <= ?`, now.Unix()); err != nil {
+		c.logger.WithError(err).Warn("Failed to expire LLM cache entries")
+	}
+	if c.max > 0 {
+		_, err := c.db.Exec(`
+			DELETE FROM llm_cache WHERE key IN (
+				SELECT key FROM llm_cache ORDER BY used_at DESC, created_at DESC LIMIT -1 OFFSET ?
+			)`, c.max)
+		if err != nil {
+			c.logger.WithError(err).Warn("Failed to trim LLM cache")
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tahcohcat/gofigure-web/config"
+)
+
+// newTestCache caches the replies of client in a database of its own
+func newTestCache(t *testing.T, client LLM, ttlMinutes, maxEntries int) *Cache {
+	t.Helper()
+
+	cfg := config.CacheConfig{
+		Enabled:    true,
+		Path:       filepath.Join(t.TempDir(), "cache.db"),
+		TTLMinutes: ttlMinutes,
+		MaxEntries: maxEntries,
+	}
+	cache, err := NewCache(client, cfg, nil)
+	if err != nil {
+		t.Fatalf("NewCache: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+	return cache
+}
+
+// opening is the opening question put to a character, with stress in the
+// prompt as it is in a game
+func opening(character, text string, stress float64, behaviour ...string) Request {
+	return Request{
+		Messages: []ChatMessage{
+			{Role: RoleSystem, Content: fmt.Sprintf("You are %s. Your stress is %.1f.", character, stress)},
+			{Role: RoleUser, Content: text},
+		},
+		Question: &Question{
+			Mystery:   "cruise_ship",
+			Character: character,
+			Text:      text,
+			Persona:   &Persona{Stress: stress, Behaviour: behaviour},
+		},
+	}
+}
+
+// cachedChat asks req through cache and reports whether the reply came from
+// the cache
+func cachedChat(t *testing.T, cache *Cache, req Request) bool {
+	t.Helper()
+
+	hits := cache.Stats().Hits
+	if _, err := cache.Chat(context.Background(), req); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	return cache.Stats().Hits > hits
+}
+
+func TestCacheKey(t *testing.T) {
+	first := opening("Captain Rodriguez", "Where were you at 9:47?", 20)
+
+	tests := []struct {
+		name string
+		req  Request
+		hit  bool
+	}{
+		{"same request", first, true},
+		{"case, spacing and punctuation", opening("Captain Rodriguez", "  where WERE you   at 9:47", 20), true},
+		{"other stress", opening("Captain Rodriguez", "Where were you at 9:47?", 35.5), true},
+		{"other question", opening("Captain Rodriguez", "Where were you at ten?", 20), false},
+		{"other character", opening("Isabella Rossi", "Where were you at 9:47?", 20), false},
+		{"stress rule behaviour", opening("Captain Rodriguez", "Where were you at 9:47?", 92, "Go cold and clinical"), false},
+		{"model override", func() Request {
+			req := opening("Captain Rodriguez", "Where were you at 9:47?", 20)
+			req.Options.Model = "other"
+			return req
+		}(), false},
+		{"one-shot prompt", Prompt("Where were you at 9:47?", Options{}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestCache(t, &fakeLLM{name: "primary"}, 0, 0)
+			if cachedChat(t, cache, first) {
+				t.Fatal("an empty cache had a reply")
+			}
+			if got := cachedChat(t, cache, tt.req); got != tt.hit {
+				t.Errorf("hit: got %v, want %v", got, tt.hit)
+			}
+		})
+	}
+}
+
+func TestCacheSkipsLaterTurns(t *testing.T) {
+	cache := newTestCache(t, &fakeLLM{name: "primary"}, 0, 0)
+
+	req := opening("Captain Rodriguez", "Where were you at 9:47?", 20)
+	req.Messages = append(req.Messages,
+		ChatMessage{Role: RoleAssistant, Content: `{"response":"On the bridge.","emotion":"neutral"}`},
+		ChatMessage{Role: RoleUser, Content: "And after that?"},
+	)
+	req.Question.Text = "And after that?"
+
+	cachedChat(t, cache, req)
+	if cachedChat(t, cache, req) {
+		t.Error("a later turn of the conversation was served from the cache")
+	}
+	if stats := cache.Stats(); stats.Skipped != 2 || stats.Entries != 0 {
+		t.Errorf("got %d skipped and %d entries, want 2 and none", stats.Skipped, stats.Entries)
+	}
+}
+
+func TestCacheStoresOnlyGoodReplies(t *testing.T) {
+	tests := []struct {
+		name   string
+		client func() LLM
+	}{
+		{"invalid reply", func() LLM {
+			return &fakeLLM{name: "primary", reply: "On the bridge, I think."}
+		}},
+		{"fallback provider", func() LLM {
+			primary, backup := &fakeLLM{name: "primary", err: errUnavailable}, &fakeLLM{name: "backup"}
+			return NewRouter([]Route{{Name: "primary", Client: primary}, {Name: "backup", Client: backup}}, 3, time.Minute)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestCache(t, tt.client(), 0, 0)
+			req := opening("Captain Rodriguez", "Where were you at 9:47?", 20)
+
+			cachedChat(t, cache, req)
+			if cachedChat(t, cache, req) {
+				t.Error("the reply was served from the cache")
+			}
+			if stats := cache.Stats(); stats.Stored != 0 {
+				t.Errorf("stored %d replies, want none", stats.Stored)
+			}
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	tests := []struct {
+		name       string
+		ttlMinutes int
+		hit        bool
+	}{
+		{"no expiry", 0, true},
+		{"expired", 60, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestCache(t, &fakeLLM{name: "primary"}, tt.ttlMinutes, 0)
+			req := opening("Captain Rodriguez", "Where were you at 9:47?", 20)
+			cachedChat(t, cache, req)
+
+			// Two hours on, a reply kept for an hour has expired
+			if _, err := cache.db.Exec(`UPDATE llm_cache SET created_at = created_at - 7200, used_at = used_at - 7200, expires_at = CASE WHEN expires_at = 0 THEN 0 ELSE expires_at - 7200 END`); err != nil {
+				t.Fatalf("failed to age the cache: %v", err)
+			}
+
+			if got := cachedChat(t, cache, req); got != tt.hit {
+				t.Errorf("hit: got %v, want %v", got, tt.hit)
+			}
+		})
+	}
+}
+
+func TestCacheTrimsLeastRecentlyUsed(t *testing.T) {
+	cache := newTestCache(t, &fakeLLM{name: "primary"}, 0, 2)
+	first := opening("Captain Rodriguez", "Where were you?", 20)
+	second := opening("Captain Rodriguez", "Who did you see?", 20)
+	third := opening("Captain Rodriguez", "What did you hear?", 20)
+
+	cachedChat(t, cache, first)
+	cachedChat(t, cache, second)
+
+	// The first reply is older, but used more recently than the second
+	if _, err := cache.db.Exec(`UPDATE llm_cache SET used_at = used_at - 60, created_at = created_at - 60`); err != nil {
+		t.Fatalf("failed to age the cache: %v", err)
+	}
+	if !cachedChat(t, cache, first) {
+		t.Fatal("the first reply was not cached")
+	}
+
+	cachedChat(t, cache, third)
+	if entries := cache.Stats().Entries; entries != 2 {
+		t.Errorf("got %d entries, want 2", entries)
+	}
+	if !cachedChat(t, cache, first) || !cachedChat(t, cache, third) {
+		t.Error("a recently used reply was dropped")
+	}
+	if cachedChat(t, cache, second) {
+		t.Error("the least recently used reply was kept")
+	}
+}
@@ -0,0 +1,48 @@
+// Package chat holds the message and reply formats shared by the LLM
+// interface and the providers that implement it
+package chat
+
+// Roles of chat messages
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is one role-tagged message of a conversation
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is what a provider is asked: the conversation, and the settings
+// to answer it with
+type Request struct {
+	Messages []Message // the conversation so far, ending with what is asked now
+	Options  Options   // model and sampling settings for this request only
+
+	// Question says who is asked what, for providers that answer without a
+	// model. It is nil for requests that only a model can answer.
+	Question *Question
+}
+
+// Response is the reply of a provider to a request, and the model that gave
+// it
+type Response struct {
+	Text  string // the reply as the model wrote it
+	Model string // the provider/model that answered
+}
+
+// Prompt returns the request for a one-shot prompt
+func Prompt(prompt string, opts Options) Request {
+	return Request{Messages: []Message{{Role: RoleUser, Content: prompt}}, Options: opts}
+}
+
+// Last returns the content of the last message, which for a one-shot prompt
+// is the prompt
+func (r Request) Last() string {
+	if len(r.Messages) == 0 {
+		return ""
+	}
+	return r.Messages[len(r.Messages)-1].Content
+}
@@ -0,0 +1,13 @@
+package chat
+
+import "fmt"
+
+// StatusError is an error status returned by a provider's API
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d", e.StatusCode)
+}
@@ -0,0 +1,23 @@
+package chat
+
+import (
+	"github.com/tahcohcat/gofigure-web/config"
+)
+
+// Options override the model and sampling settings of a provider for some
+// requests, such as the questions put to one character. The zero value
+// keeps the settings of the provider.
+type Options struct {
+	Model string `json:"model,omitempty"`
+	config.SamplingConfig
+}
+
+// Resolve applies opts to a provider's model and sampling settings. Every
+// provider resolves its settings this way, so an override means the same
+// thing whichever provider is in use.
+func Resolve(opts Options, model string, sampling config.SamplingConfig) (string, config.SamplingConfig) {
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	return model, sampling.Merge(opts.SamplingConfig)
+}
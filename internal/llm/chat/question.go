@@ -0,0 +1,34 @@
+package chat
+
+import "strings"
+
+// Question is what a request asks, for providers that answer without a model
+// and so can't read it out of the prompt. It travels on the request, next to
+// the messages that put the same question to a model.
+type Question struct {
+	Mystery   string   // the id of the mystery the character is in, if known
+	Character string   // who is asked
+	Text      string   // the detective's question as typed, empty for a one-shot prompt
+	Persona   *Persona // what the character knows and may say
+}
+
+// Persona is what a provider needs to know about a character to answer as
+// them without a model
+type Persona struct {
+	Personality string
+	Reliable    bool
+	Stress      float64  // from 0 to 100
+	Knowledge   []string // what the character knows, as written in the mystery
+	Secrets     []string // secrets the character has already admitted
+	Confess     []string // secrets the character must admit in this answer
+	Mention     []string // things to bring up in this answer
+	Behaviour   []string // how the stress rules that apply say to behave
+}
+
+// Normalize makes questions and prompts that differ only in case, spacing or
+// final punctuation the same, so "Where were you at 9:47?" and "where were
+// you at 9:47" share a reply
+func Normalize(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	return strings.TrimRight(text, "?!. ")
+}
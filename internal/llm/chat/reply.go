@@ -0,0 +1,32 @@
+package chat
+
+import (
+	"encoding/json"
+
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
+)
+
+// MaxReplyLength is the longest response text a reply may have, in characters
+const MaxReplyLength = 1200
+
+// ReplySchema is the JSON schema of a reply, for providers that can hold the
+// model to a schema. The length limit is left out because not every
+// provider supports it in strict mode.
+var ReplySchema = mustMarshal(map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"response":  map[string]interface{}{"type": "string"},
+		"emotion":   map[string]interface{}{"type": "string", "enum": emotion.Names()},
+		"intensity": map[string]interface{}{"type": "number"},
+	},
+	"required":             []string{"response", "emotion", "intensity"},
+	"additionalProperties": false,
+})
+
+func mustMarshal(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -3,26 +3,83 @@ package llm
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm/offline"
 	"github.com/tahcohcat/gofigure-web/internal/llm/ollama"
 	"github.com/tahcohcat/gofigure-web/internal/llm/openai"
+	"github.com/tahcohcat/gofigure-web/internal/llm/replay"
 )
 
 type Provider string
 
 const (
-	ProviderOllama Provider = "ollama"
-	ProviderOpenAI Provider = "openai"
+	ProviderOllama  Provider = "ollama"
+	ProviderOpenAI  Provider = "openai"
+	ProviderReplay  Provider = "replay"
+	ProviderOffline Provider = "offline"
 )
 
-// NewLLMClient creates a new LLM client based on the configuration
+// NewLLMClient creates a new LLM client based on the configuration. With
+// more than one provider in llm.providers it is a Router over them, and with
+// llm.cache enabled its replies are cached.
 func NewLLMClient(cfg *config.Config) (LLM, error) {
-	switch Provider(cfg.LLM.Provider) {
+	client, err := newClient(cfg)
+	if err != nil || !cfg.LLM.Cache.Enabled {
+		return client, err
+	}
+
+	sampling := map[string]config.SamplingConfig{
+		string(ProviderOllama): cfg.Ollama.Sampling,
+		string(ProviderOpenAI): cfg.OpenAI.Sampling,
+	}
+	return NewCache(client, cfg.LLM.Cache, sampling)
+}
+
+func newClient(cfg *config.Config) (LLM, error) {
+	names := cfg.LLM.Providers
+	if len(names) == 0 {
+		names = []string{cfg.LLM.Provider}
+	}
+
+	routes := make([]Route, 0, len(names))
+	for _, name := range names {
+		client, err := newProvider(Provider(name), cfg)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, Route{Name: name, Client: client})
+	}
+
+	if len(routes) == 1 {
+		return routes[0].Client, nil
+	}
+	cooldown := time.Duration(cfg.LLM.Breaker.CooldownSeconds) * time.Second
+	return NewRouter(routes, cfg.LLM.Breaker.Failures, cooldown), nil
+}
+
+func newProvider(provider Provider, cfg *config.Config) (LLM, error) {
+	switch provider {
 	case ProviderOllama:
 		return ollama.NewClient(&cfg.Ollama)
 	case ProviderOpenAI:
 		return openai.NewClient(&cfg.OpenAI)
+	case ProviderReplay:
+		var recorded LLM
+		if replay.Mode(cfg.Replay.Mode) == replay.ModeRecord {
+			if Provider(cfg.Replay.Provider) == ProviderReplay {
+				return nil, fmt.Errorf("the replay provider can't record itself")
+			}
+			var err error
+			if recorded, err = newProvider(Provider(cfg.Replay.Provider), cfg); err != nil {
+				return nil, err
+			}
+		}
+		return replay.NewClient(&cfg.Replay, recorded)
+	case ProviderOffline:
+		return offline.NewClient(), nil
 	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s", cfg.LLM.Provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s", provider)
 	}
 }
@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// healthCheckTimeout bounds a single IsModelAvailable call
+const healthCheckTimeout = 10 * time.Second
+
+// HealthStatus is the outcome of the last model availability check. It is
+// ready only when the model of the client and every override are available.
+type HealthStatus struct {
+	Model     string        `json:"model"`
+	Ready     bool          `json:"ready"`
+	Error     string        `json:"error,omitempty"`
+	Overrides []ModelHealth `json:"overrides,omitempty"` // models some characters ask for instead
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// ModelHealth is whether one model is available
+type ModelHealth struct {
+	Model string `json:"model"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+// Health checks that the model of a client, and the models some requests
+// ask for instead, are available, once when it is created and then every
+// interval
+type Health struct {
+	client    LLM
+	overrides func() []string // models requests may ask for instead
+
+	mu     sync.RWMutex
+	status HealthStatus
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewHealth checks the model of client, and the models overrides returns if
+// it isn't nil, and keeps checking them every interval until Close is
+// called. A zero interval checks only once.
+func NewHealth(client LLM, overrides func() []string, interval time.Duration) *Health {
+	h := &Health{
+		client:    client,
+		overrides: overrides,
+		stop:      make(chan struct{}),
+	}
+	h.Check()
+
+	if interval > 0 {
+		go h.run(interval)
+	}
+	return h
+}
+
+// Check asks the provider whether the models are available and records the
+// answer
+func (h *Health) Check() HealthStatus {
+	model := h.check(Options{})
+	status := HealthStatus{Model: model.Model, Ready: model.Ready, Error: model.Error, CheckedAt: time.Now()}
+
+	if h.overrides != nil {
+		for _, name := range h.overrides() {
+			override := h.check(Options{Model: name})
+			if override.Model == status.Model {
+				continue
+			}
+			if !override.Ready && status.Ready {
+				status.Ready = false
+				status.Error = fmt.Sprintf("model %s: %s", override.Model, override.Error)
+			}
+			status.Overrides = append(status.Overrides, override)
+		}
+	}
+
+	h.mu.Lock()
+	h.status = status
+	h.mu.Unlock()
+
+	return status
+}
+
+// check asks the provider whether the model requests with opts use is
+// available
+func (h *Health) check(opts Options) ModelHealth {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	model := ModelHealth{Model: ModelName(h.client, opts), Ready: true}
+	if err := h.client.IsModelAvailable(ctx, opts); err != nil {
+		model.Ready = false
+		model.Error = err.Error()
+	}
+	return model
+}
+
+// Status returns the outcome of the last check
+func (h *Health) Status() HealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
+
+// Close stops the periodic checks
+func (h *Health) Close() {
+	h.stopOnce.Do(func() { close(h.stop) })
+}
+
+func (h *Health) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.Check()
+		case <-h.stop:
+			return
+		}
+	}
+}
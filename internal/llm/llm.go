@@ -3,19 +3,94 @@ package llm
 
 import (
 	"context"
+
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// ChatMessage is one role-tagged message of a conversation
+type ChatMessage = chat.Message
+
+// Options override the model and sampling settings for some requests
+type Options = chat.Options
+
+// Request is what a provider is asked
+type Request = chat.Request
+
+// Response is the reply of a provider, and the model that gave it
+type Response = chat.Response
+
+// Prompt returns the request for a one-shot prompt
+func Prompt(prompt string, opts Options) Request {
+	return chat.Prompt(prompt, opts)
+}
+
+// Question is what a request asks, for providers that answer without a model
+type Question = chat.Question
+
+// Persona is what a provider needs to know about a character to answer as
+// them without a model
+type Persona = chat.Persona
+
+// Roles of chat messages
+const (
+	RoleSystem    = chat.RoleSystem
+	RoleUser      = chat.RoleUser
+	RoleAssistant = chat.RoleAssistant
 )
 
 type CharacterReply struct {
-	Response string `json:"response"`
-	Emotion  string `json:"emotion"`
+	Response  string  `json:"response"`
+	Emotion   string  `json:"emotion"`
+	Intensity float64 `json:"intensity,omitempty"` // how strongly the emotion is felt, from 0 to 1
+	Model     string  `json:"-"`                   // the provider/model that answered
 }
 
-// LLM defines the interface for language model providers
+// LLM defines the interface for language model providers.
+//
+// Every provider applies the Options of a request with chat.Resolve, so
+// without them the configured settings are used. The Question of a request
+// says who is asked what, for providers that answer without a model: they
+// fail with an error that says so when it is missing, rather than guess.
+// Every other provider answers from the messages alone.
 type LLM interface {
 
-	// GenerateResponse generates a response from the LLM given a prompt
-	GenerateResponse(ctx context.Context, prompt string) (string, error)
+	// GenerateResponse generates a response to a one-shot prompt, the single
+	// message of req
+	GenerateResponse(ctx context.Context, req Request) (Response, error)
+
+	// Chat generates the next assistant message of the conversation of req
+	Chat(ctx context.Context, req Request) (Response, error)
+
+	// IsModelAvailable checks if the model requests with opts would use is
+	// available: the configured one, unless opts overrides it
+	IsModelAvailable(ctx context.Context, opts Options) error
+}
+
+// TokenFunc receives each piece of a streamed reply as it arrives. Returning
+// an error stops the stream.
+type TokenFunc = func(token string) error
+
+// Streamer is implemented by providers that can stream a chat reply
+type Streamer interface {
+
+	// ChatStream works like Chat but passes the reply to onToken as it is
+	// generated. It returns the whole reply once the stream ends.
+	ChatStream(ctx context.Context, req Request, onToken TokenFunc) (Response, error)
+}
+
+// ChatStream streams the reply of client if it is a Streamer, and otherwise
+// passes the whole reply to onToken at once
+func ChatStream(ctx context.Context, client LLM, req Request, onToken TokenFunc) (Response, error) {
+	if streamer, ok := client.(Streamer); ok {
+		return streamer.ChatStream(ctx, req, onToken)
+	}
 
-	// IsModelAvailable checks if the configured model is available
-	IsModelAvailable(ctx context.Context) error
+	reply, err := client.Chat(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+	if err := onToken(reply.Text); err != nil {
+		return Response{}, err
+	}
+	return reply, nil
 }
@@ -0,0 +1,185 @@
+// Package offline is an LLM provider that needs no model at all. It answers
+// a question with the lines of a character's knowledge that best match it,
+// phrased to suit the character's personality. The answers are plainer than
+// a model's, but a game can be played on a machine without one, and the
+// answers are the same every time for tests.
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// maxFacts is the most lines of knowledge an answer uses
+const maxFacts = 2
+
+// ErrNoPersona is returned for requests made without a chat.Question, or
+// with a question that doesn't describe the character asked
+var ErrNoPersona = errors.New("the offline engine can only answer questions put to a character")
+
+type Client struct{}
+
+func NewClient() *Client {
+	return &Client{}
+}
+
+// GenerateResponse answers a one-shot prompt, such as a narration, with
+// everything the character knows
+func (c *Client) GenerateResponse(ctx context.Context, req chat.Request) (chat.Response, error) {
+	return c.answer(req)
+}
+
+// Chat answers the question of req. The messages are not read: they are
+// written for a model, and what the character knows can't be told from them.
+func (c *Client) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	return c.answer(req)
+}
+
+func (c *Client) IsModelAvailable(ctx context.Context, opts chat.Options) error {
+	return nil
+}
+
+func (c *Client) ModelName(opts chat.Options) string {
+	return "offline/rules"
+}
+
+func (c *Client) answer(req chat.Request) (chat.Response, error) {
+	q := req.Question
+	if q == nil {
+		return chat.Response{}, fmt.Errorf("%w: the request was made without a question", ErrNoPersona)
+	}
+	p := q.Persona
+	if p == nil {
+		return chat.Response{}, fmt.Errorf("%w: the question to %s has no persona", ErrNoPersona, q.Character)
+	}
+
+	v := voiceFor(p.Personality)
+	reply := struct {
+		Response  string  `json:"response"`
+		Emotion   string  `json:"emotion"`
+		Intensity float64 `json:"intensity"`
+	}{Emotion: string(v.emotion), Intensity: emotion.DefaultIntensity}
+
+	if strings.TrimSpace(q.Text) == "" {
+		reply.Response = strings.Join(p.Knowledge, " ")
+	} else {
+		reply.Response = c.compose(q, v)
+	}
+
+	// Stress shows through whatever the character's usual manner
+	switch {
+	case len(p.Confess) > 0:
+		reply.Emotion, reply.Intensity = string(emotion.Nervous), 0.85
+	case p.Stress >= 70:
+		reply.Emotion, reply.Intensity = string(emotion.Defensive), 0.8
+	case !p.Reliable && v.emotion == emotion.Neutral:
+		reply.Emotion = string(emotion.Defensive)
+	}
+	if reply.Response == "" {
+		reply.Response = pick(v.unknown, q.Character)
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return chat.Response{}, err
+	}
+	return chat.Response{Text: string(data), Model: c.ModelName(req.Options)}, nil
+}
+
+// compose builds the answer to a question from the best matching lines of
+// knowledge, any secret that must come out and anything to mention
+func (c *Client) compose(q *chat.Question, v voice) string {
+	p := q.Persona
+	seed := q.Character + "\x00" + q.Text
+
+	// Admitted secrets are fair game, the rest stay hidden
+	lines := append(p.Knowledge[:len(p.Knowledge):len(p.Knowledge)], p.Secrets...)
+	matches := newIndex(lines).search(query(q.Text))
+
+	var facts []string
+	for _, m := range matches {
+		if len(facts) == maxFacts || m.score < matches[0].score/2 {
+			break
+		}
+		facts = append(facts, firstPerson(lines[m.line]))
+	}
+
+	if len(facts) == 0 && len(p.Confess) == 0 && len(p.Mention) == 0 {
+		if p.Reliable {
+			return pick(v.unknown, seed)
+		}
+		return pick(evasiveUnknown, seed)
+	}
+
+	// An unreliable character hedges instead of opening in their usual manner
+	var parts []string
+	if len(facts) > 0 && !p.Reliable {
+		parts = append(parts, pick(evasiveOpeners, seed+"evasive")+" "+lowerFirst(facts[0]))
+		facts = facts[1:]
+	} else {
+		parts = append(parts, pick(v.openers, seed+"opener"))
+	}
+	if len(facts) > 0 {
+		parts = append(parts, facts[0])
+		if len(facts) > 1 {
+			parts = append(parts, "And "+lowerFirst(facts[1]))
+		}
+	}
+
+	for i, secret := range p.Confess {
+		if i == 0 {
+			parts = append(parts, pick(confessions, seed+"confess"))
+		}
+		parts = append(parts, firstPerson(secret))
+	}
+	for _, mention := range p.Mention {
+		parts = append(parts, "There's something else you should know: "+lowerFirst(sentence(mention)))
+	}
+
+	parts = append(parts, pick(v.closers, seed+"closer"))
+	return truncate(join(parts), chat.MaxReplyLength)
+}
+
+// pick chooses one of options by a hash of seed, so the same question gets
+// the same answer every time
+func pick(options []string, seed string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return options[h.Sum32()%uint32(len(options))]
+}
+
+// join puts the non-empty parts of an answer together
+func join(parts []string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// truncate shortens text to at most limit characters, at the end of a
+// sentence
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	cut := string(runes[:limit])
+	if i := strings.LastIndexAny(cut, ".!?"); i > 0 {
+		return cut[:i+1]
+	}
+	return cut
+}
@@ -0,0 +1,134 @@
+package offline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// ask puts question to a character with persona and decodes the answer
+func ask(t *testing.T, question string, persona *chat.Persona) (response, emotion string) {
+	t.Helper()
+
+	req := chat.Prompt(question, chat.Options{})
+	req.Question = &chat.Question{Character: "Captain Rodriguez", Text: question, Persona: persona}
+	reply, err := NewClient().Chat(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	var got struct {
+		Response string `json:"response"`
+		Emotion  string `json:"emotion"`
+	}
+	if err := json.Unmarshal([]byte(reply.Text), &got); err != nil {
+		t.Fatalf("reply %s is not JSON: %v", reply, err)
+	}
+	return got.Response, got.Emotion
+}
+
+func testPersona() *chat.Persona {
+	return &chat.Persona{
+		Personality: "Stern and proud",
+		Reliable:    true,
+		Stress:      30,
+		Knowledge:   knowledge,
+	}
+}
+
+func TestAnswerConfessAndMention(t *testing.T) {
+	const secret = "I moved the chef's body into the freezer"
+	const mention = "The galley door was open at midnight"
+
+	tests := []struct {
+		name     string
+		confess  []string
+		mention  []string
+		admitted []string
+		want     []string
+		hidden   []string
+		emotion  string
+	}{
+		{
+			name:    "nothing to confess",
+			want:    []string{"freezer"},
+			hidden:  []string{"moved the chef's body"},
+			emotion: "confident",
+		},
+		{
+			name:    "confession",
+			confess: []string{secret},
+			want:    []string{"moved the chef's body into the freezer"},
+			emotion: "nervous",
+		},
+		{
+			name:    "mention",
+			mention: []string{mention},
+			want:    []string{"There's something else you should know: the galley door was open at midnight."},
+			hidden:  []string{"moved the chef's body"},
+			emotion: "confident",
+		},
+		{
+			name:     "admitted secret matches",
+			admitted: []string{secret},
+			want:     []string{"moved the chef's body"},
+			emotion:  "confident",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPersona()
+			p.Confess, p.Mention, p.Secrets = tt.confess, tt.mention, tt.admitted
+
+			response, emotion := ask(t, "What do you know about the freezer body?", p)
+			for _, want := range tt.want {
+				if !strings.Contains(response, want) {
+					t.Errorf("answer %q doesn't contain %q", response, want)
+				}
+			}
+			for _, hidden := range tt.hidden {
+				if strings.Contains(response, hidden) {
+					t.Errorf("answer %q gives away %q", response, hidden)
+				}
+			}
+			if emotion != tt.emotion {
+				t.Errorf("got emotion %s, want %s", emotion, tt.emotion)
+			}
+		})
+	}
+}
+
+func TestAnswerIsStable(t *testing.T) {
+	first, _ := ask(t, "Who has a key to the freezer?", testPersona())
+	second, _ := ask(t, "Who has a key to the freezer?", testPersona())
+	if first != second {
+		t.Errorf("the same question got %q and then %q", first, second)
+	}
+}
+
+func TestAnswerWithoutPersona(t *testing.T) {
+	client := NewClient()
+
+	tests := []struct {
+		name     string
+		question *chat.Question
+	}{
+		{"no question", nil},
+		{"no persona", &chat.Question{Character: "Captain Rodriguez", Text: "Where were you?"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := chat.Prompt("Where were you?", chat.Options{})
+			req.Question = tt.question
+			if _, err := client.Chat(context.Background(), req); !errors.Is(err, ErrNoPersona) {
+				t.Errorf("got error %v, want ErrNoPersona", err)
+			}
+		})
+	}
+}
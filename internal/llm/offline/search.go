@@ -0,0 +1,208 @@
+package offline
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters, at their usual values
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// relatedWeight is how much a word related to one in the question counts,
+// next to the word itself
+const relatedWeight = 0.5
+
+// stopwords carry no meaning for matching a question to a line of knowledge
+var stopwords = toSet(`a about after again all am an and any anything are as at be been before being
+both but by can could did do does doing don done for from get got had has have having he her here
+hers him his how i if in into is it its just know let like me might more most my no nor not now of
+on once only or other our out over own please really same say she should so some something tell
+than that the their them then there these they think this those through to too up us very was we
+were what which while who whom why will with would you your yours`)
+
+// related maps words onto others that a question using them is likely after
+var related = stemRelated(map[string][]string{
+	"alibi":        {"where", "evening", "night", "during"},
+	"where":        {"alibi", "evening", "night", "during"},
+	"when":         {"time", "pm", "am", "evening", "night", "morning"},
+	"time":         {"when", "pm", "am", "evening", "night", "morning"},
+	"kill":         {"murder", "death", "dead", "die", "body"},
+	"murder":       {"kill", "death", "dead", "die", "body"},
+	"death":        {"murder", "kill", "dead", "die", "body"},
+	"die":          {"murder", "kill", "death", "dead", "body"},
+	"dead":         {"murder", "kill", "death", "die", "body"},
+	"body":         {"murder", "death", "dead", "found"},
+	"see":          {"saw", "seen", "notice", "witness", "watch"},
+	"saw":          {"see", "seen", "notice", "witness", "watch"},
+	"notice":       {"see", "saw", "seen", "witness"},
+	"witness":      {"see", "saw", "seen", "notice"},
+	"hear":         {"heard", "overheard", "voice", "scream"},
+	"heard":        {"hear", "overheard", "voice", "scream"},
+	"argue":        {"argument", "argu", "fight", "quarrel", "heat"},
+	"argument":     {"argue", "argu", "fight", "quarrel", "heat"},
+	"fight":        {"argue", "argu", "argument", "quarrel"},
+	"money":        {"debt", "pay", "owe", "fund", "budget", "cost", "embezzl"},
+	"debt":         {"money", "owe", "pay", "loan"},
+	"secret":       {"hide", "hidd", "lie", "lying", "truth"},
+	"lie":          {"lying", "secret", "truth", "hide"},
+	"relationship": {"affair", "wife", "husband", "married", "love"},
+	"affair":       {"relationship", "love", "married"},
+	"key":          {"lock", "access", "door"},
+	"lock":         {"key", "access", "door"},
+	"weapon":       {"knife", "gun", "candlestick", "poison"},
+	"victim":       {"murder", "death", "body"},
+})
+
+// terms splits text into stemmed words, leaving out stopwords
+func terms(text string) []string {
+	var out []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		word = strings.Trim(word, "'")
+		word = strings.TrimSuffix(word, "'s")
+		if word == "" || len(word) < 2 && !unicode.IsDigit(rune(word[0])) {
+			continue
+		}
+		if stopwords[word] {
+			continue
+		}
+		out = append(out, stem(word))
+	}
+	return out
+}
+
+// stem strips common English endings so that "argue", "argued" and
+// "arguing" match. The stems need not be words, only agree with each other.
+func stem(word string) string {
+	switch {
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		word = strings.TrimSuffix(word, "ing")
+	case len(word) > 4 && strings.HasSuffix(word, "ied"):
+		word = strings.TrimSuffix(word, "ied") + "y"
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		word = strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		word = strings.TrimSuffix(word, "ed")
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		word = strings.TrimSuffix(word, "s")
+	}
+
+	if len(word) > 3 && strings.HasSuffix(word, "e") {
+		word = strings.TrimSuffix(word, "e")
+	}
+	return word
+}
+
+// query weighs the terms of a question, adding the words related to them at
+// a lower weight
+func query(question string) map[string]float64 {
+	weights := make(map[string]float64)
+	for _, term := range terms(question) {
+		weights[term] = 1
+	}
+	for term, weight := range weights {
+		if weight < 1 {
+			continue
+		}
+		for _, word := range related[term] {
+			if weights[word] < relatedWeight {
+				weights[word] = relatedWeight
+			}
+		}
+	}
+	return weights
+}
+
+// stemRelated stems the words of a map of related words, so it can be
+// looked up with terms
+func stemRelated(words map[string][]string) map[string][]string {
+	stemmed := make(map[string][]string, len(words))
+	for word, others := range words {
+		for _, other := range others {
+			stemmed[stem(word)] = append(stemmed[stem(word)], stem(other))
+		}
+	}
+	return stemmed
+}
+
+// index ranks lines of text against a query with BM25
+type index struct {
+	lines  [][]string
+	df     map[string]int // lines each term appears in
+	avgLen float64
+}
+
+func newIndex(lines []string) *index {
+	ix := &index{df: make(map[string]int)}
+
+	total := 0
+	for _, line := range lines {
+		words := terms(line)
+		ix.lines = append(ix.lines, words)
+		total += len(words)
+
+		seen := make(map[string]bool)
+		for _, word := range words {
+			if !seen[word] {
+				seen[word] = true
+				ix.df[word]++
+			}
+		}
+	}
+	if len(lines) > 0 {
+		ix.avgLen = float64(total) / float64(len(lines))
+	}
+
+	return ix
+}
+
+// match is a line and how well it matches a query
+type match struct {
+	line  int
+	score float64
+}
+
+// search returns the lines that share a term with q, best first
+func (ix *index) search(q map[string]float64) []match {
+	n := float64(len(ix.lines))
+
+	var matches []match
+	for i, words := range ix.lines {
+		counts := make(map[string]int)
+		for _, word := range words {
+			counts[word]++
+		}
+
+		score := 0.0
+		for term, weight := range q {
+			tf := float64(counts[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(ix.df[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := tf + bm25K1*(1-bm25B+bm25B*float64(len(words))/ix.avgLen)
+			score += weight * idf * tf * (bm25K1 + 1) / norm
+		}
+		if score > 0 {
+			matches = append(matches, match{line: i, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	return matches
+}
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,70 @@
+package offline
+
+import "testing"
+
+var knowledge = []string{
+	"The captain argued with the chef about the budget",
+	"The freezer locks from the outside",
+	"Dinner was served at eight in the dining room",
+	"The chef kept a spare key to the freezer in the galley",
+}
+
+func TestSearchRanking(t *testing.T) {
+	tests := []struct {
+		name     string
+		question string
+		best     int // the line that should rank first, or -1 for no match
+	}{
+		{"shared words", "Who argued with the chef?", 0},
+		{"stemmed words", "Was there an argument? Who was arguing?", 0},
+		{"rarer word ranks higher", "Who has a key to the freezer?", 3},
+		{"related words", "Where were you during the evening at dinner?", 2},
+		{"only stopwords", "What do you know about it?", -1},
+		{"nothing in common", "Do you like jazz?", -1},
+	}
+
+	ix := newIndex(knowledge)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := ix.search(query(tt.question))
+			if tt.best < 0 {
+				if len(matches) > 0 {
+					t.Errorf("got %d matches, want none: best is %q", len(matches), knowledge[matches[0].line])
+				}
+				return
+			}
+			if len(matches) == 0 {
+				t.Fatal("got no matches")
+			}
+			if matches[0].line != tt.best {
+				t.Errorf("best match is %q, want %q", knowledge[matches[0].line], knowledge[tt.best])
+			}
+			for i := 1; i < len(matches); i++ {
+				if matches[i].score > matches[i-1].score {
+					t.Errorf("match %d scores %.3f, above match %d at %.3f", i, matches[i].score, i-1, matches[i-1].score)
+				}
+			}
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		words []string
+		stem  string
+	}{
+		{[]string{"argue", "argued", "argues"}, "argu"},
+		{[]string{"lock", "locks", "locked", "locking"}, "lock"},
+		{[]string{"carry", "carried", "carries"}, "carry"},
+		{[]string{"glass", "glasses"}, "glass"},
+		{[]string{"bus"}, "bus"},
+	}
+
+	for _, tt := range tests {
+		for _, word := range tt.words {
+			if got := stem(word); got != tt.stem {
+				t.Errorf("stem(%q) = %q, want %q", word, got, tt.stem)
+			}
+		}
+	}
+}
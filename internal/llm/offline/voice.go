@@ -0,0 +1,280 @@
+package offline
+
+import (
+	"strings"
+
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
+)
+
+// voice is how a kind of character phrases an answer
+type voice struct {
+	traits  []string // words in a personality that call for this voice
+	emotion emotion.Emotion
+	openers []string // said before the answer; an empty one says nothing
+	closers []string // said after it
+	unknown []string // said when the character knows nothing about the question
+}
+
+// voices are tried in order; the one with the most traits in a personality is used
+var voices = []voice{
+	{
+		traits:  []string{"nervous", "anxious", "overwhelmed", "shy", "jumpy", "young"},
+		emotion: emotion.Nervous,
+		openers: []string{"I- well...", "Um, okay.", "Sorry, I'm a bit on edge.", ""},
+		closers: []string{"Is that... is that enough?", "Can I go now?", ""},
+		unknown: []string{"I... I really don't know anything about that.", "I'm sorry, I don't know. Honestly."},
+	},
+	{
+		traits:  []string{"gruff", "authoritative", "direct", "military", "serious", "stern"},
+		emotion: emotion.Confident,
+		openers: []string{"Listen carefully.", "Here's what I know.", ""},
+		closers: []string{"That's all there is to it.", "Make of that what you will.", ""},
+		unknown: []string{"I can't help you there.", "Not something I know about."},
+	},
+	{
+		traits:  []string{"cheerful", "friendly", "sweet", "helpful", "eager", "earnest", "compassionate"},
+		emotion: emotion.Happy,
+		openers: []string{"Oh, of course!", "Happy to help!", "Let me think...", ""},
+		closers: []string{"Anything else I can do for you?", "I hope that helps!", ""},
+		unknown: []string{"Oh dear, I'm afraid I don't know anything about that.", "I wish I could help with that, I really do."},
+	},
+	{
+		traits:  []string{"gossip", "gossipy", "nosy"},
+		emotion: emotion.Excited,
+		openers: []string{"Well, you didn't hear it from me, but...", "Oh, I can tell you all about that!", ""},
+		closers: []string{"Mark my words.", "But that's just what I've noticed.", ""},
+		unknown: []string{"For once I haven't heard a thing!", "Now that, I don't know. Yet."},
+	},
+	{
+		traits:  []string{"calm", "logical", "professional", "observant", "brilliant", "sharp-witted", "analytical"},
+		emotion: emotion.Calm,
+		openers: []string{"Let me be precise.", "I'll tell you what I observed.", ""},
+		closers: []string{"I hope that's clear.", ""},
+		unknown: []string{"I have no information on that.", "I couldn't tell you, I'm afraid."},
+	},
+	{
+		traits:  []string{"elegant", "dramatic", "aristocratic", "charming", "smooth", "socialite"},
+		emotion: emotion.Sad,
+		openers: []string{"Oh, Detective, it's all simply dreadful.", "Darling, if you must know...", ""},
+		closers: []string{"I can hardly bear to think of it.", ""},
+		unknown: []string{"I really wouldn't know about such things, darling.", "How should I know?"},
+	},
+	{
+		traits:  []string{"mysterious", "evasive", "enigmatic", "secretive"},
+		emotion: emotion.Mysterious,
+		openers: []string{"Perhaps.", "Some things are better left unsaid, but...", ""},
+		closers: []string{"Draw your own conclusions.", ""},
+		unknown: []string{"That is not something I can speak to.", "Who can say?"},
+	},
+}
+
+// plain is the voice of a character whose personality matches no other
+var plain = voice{
+	emotion: emotion.Neutral,
+	openers: []string{"Well.", ""},
+	closers: []string{""},
+	unknown: []string{"I don't know anything about that.", "I'm afraid I can't help you with that."},
+}
+
+// evasive phrasings of an unreliable character
+var (
+	evasiveOpeners = []string{
+		"Why do you want to know? Well...",
+		"I suppose I can tell you this much:",
+		"If you must know,",
+		"I don't see why it matters, but",
+	}
+	evasiveUnknown = []string{
+		"I couldn't say.",
+		"I don't recall, and I don't see why it matters.",
+		"That's none of my business, Detective. Or yours.",
+	}
+)
+
+// confessions lead into a secret the character can no longer keep
+var confessions = []string{
+	"All right... all right. The truth is,",
+	"Fine. You want the truth?",
+	"I can't keep this up.",
+}
+
+// voiceFor picks the voice whose traits best match personality
+func voiceFor(personality string) voice {
+	personality = strings.ToLower(personality)
+
+	best, bestCount := plain, 0
+	for _, v := range voices {
+		count := 0
+		for _, trait := range v.traits {
+			if strings.Contains(personality, trait) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = v, count
+		}
+	}
+	return best
+}
+
+// reporters are the verbs a mystery starts knowledge with when the
+// character's own words are reported, and what the character says instead
+var reporters = map[string]string{
+	"claims":    "",
+	"says":      "",
+	"mentions":  "",
+	"knows":     "I know",
+	"confirms":  "I can confirm",
+	"believes":  "I believe",
+	"suspects":  "I suspect",
+	"admits":    "I admit",
+	"is":        "I'm",
+	"was":       "I was",
+	"has":       "I have",
+	"had":       "I had",
+	"having":    "I'm having",
+	"nervous":   "I'm nervous",
+	"saw":       "I saw",
+	"heard":     "I heard",
+	"overheard": "I overheard",
+	"found":     "I found",
+	"noticed":   "I noticed",
+}
+
+// firstPerson turns a line of knowledge, which a mystery writes about the
+// character ("Says he was in the kitchen"), into something the character
+// can say ("I was in the kitchen")
+func firstPerson(line string) string {
+	line = strings.TrimSuffix(strings.TrimSpace(line), ".")
+	if line == "" {
+		return ""
+	}
+
+	words := strings.SplitN(line, " ", 2)
+	first, rest := strings.ToLower(words[0]), ""
+	if len(words) == 2 {
+		rest = words[1]
+	}
+
+	if say, ok := reporters[first]; ok {
+		switch {
+		case say != "":
+			return sentence(say + " " + possessives(rest))
+		case strings.HasPrefix(rest, "to be "):
+			return sentence("I'm " + strings.TrimPrefix(rest, "to be "))
+		default:
+			line = rest
+		}
+	} else if strings.HasSuffix(first, "ly") && first != "only" {
+		// "Desperately needs money"
+		return sentence("I " + strings.ToLower(words[0][:1]) + words[0][1:] + " " + conjugate(rest))
+	}
+
+	return sentence(ownSubject(line))
+}
+
+// ownSubject puts the character in place of a he or she that starts line
+func ownSubject(line string) string {
+	lower := strings.ToLower(line)
+	for _, pronoun := range []string{"he", "she"} {
+		switch {
+		case strings.HasPrefix(lower, pronoun+"'s been "):
+			return "I've been " + line[len(pronoun+"'s been "):]
+		case strings.HasPrefix(lower, pronoun+"'s "):
+			rest := line[len(pronoun+"'s "):]
+			if next := strings.SplitN(rest, " ", 2)[0]; strings.HasSuffix(next, "ed") {
+				return "I've " + possessives(rest)
+			}
+			return "I'm " + possessives(rest)
+		case strings.HasPrefix(lower, pronoun+" "):
+			return "I " + possessives(conjugate(line[len(pronoun+" "):]))
+		}
+	}
+	return line
+}
+
+// possessives makes the his or her of a sentence about the character "my"
+func possessives(text string) string {
+	text = strings.NewReplacer(
+		" but is ", " but am ", " and is ", " and am ",
+		" but has ", " but have ", " and has ", " and have ",
+		" but hasn't ", " but haven't ", " and hasn't ", " and haven't ",
+	).Replace(" " + text + " ")
+	text = strings.TrimSpace(text)
+
+	for _, own := range []string{"his own ", "her own "} {
+		text = strings.ReplaceAll(text, own, "my own ")
+	}
+	for _, noun := range []string{"mother", "father", "husband", "wife", "family", "sister", "brother", "son", "daughter", "sick", "employer", "job", "age", "whereabouts"} {
+		text = strings.ReplaceAll(text, "his "+noun, "my "+noun)
+		text = strings.ReplaceAll(text, "her "+noun, "my "+noun)
+	}
+	return text
+}
+
+// conjugate turns the verb at the start of text from "he knows" to "I know",
+// past any adverbs
+func conjugate(text string) string {
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		if strings.HasSuffix(word, "ly") && i < len(words)-1 {
+			continue
+		}
+
+		switch word {
+		case "is":
+			words[i] = "am"
+		case "has":
+			words[i] = "have"
+		case "does":
+			words[i] = "do"
+		case "hasn't":
+			words[i] = "haven't"
+		case "doesn't":
+			words[i] = "don't"
+		case "isn't":
+			words[i] = "am not"
+		case "was", "wasn't":
+		default:
+			switch {
+			case strings.HasSuffix(word, "ies"):
+				words[i] = strings.TrimSuffix(word, "ies") + "y"
+			case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"):
+				words[i] = strings.TrimSuffix(word, "es")
+			case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+				words[i] = strings.TrimSuffix(word, "s")
+			}
+		}
+		break
+	}
+	return strings.Join(words, " ")
+}
+
+// sentence starts text with a capital and ends it with a full stop
+func sentence(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+
+	text = strings.ToUpper(text[:1]) + text[1:]
+	if !strings.ContainsAny(text[len(text)-1:], ".!?") {
+		text += "."
+	}
+	return text
+}
+
+// lowerFirst lowers the first letter of text, unless it starts a name or "I"
+func lowerFirst(text string) string {
+	if text == "" || strings.HasPrefix(text, "I ") || strings.HasPrefix(text, "I'") {
+		return text
+	}
+	first := strings.SplitN(text, " ", 2)[0]
+	if first != strings.ToLower(first) && !commonStarts[strings.ToLower(first)] {
+		return text
+	}
+	return strings.ToLower(text[:1]) + text[1:]
+}
+
+// commonStarts are capitalised words that start a sentence rather than a name
+var commonStarts = toSet("the a an there someone no only my he she it they this that some")
@@ -2,9 +2,14 @@ package ollama
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
 	"github.com/tahcohcat/gofigure-web/internal/logger"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/ollama/ollama/api"
@@ -17,9 +22,19 @@ type Client struct {
 }
 
 func NewClient(cfg *config.OllamaConfig) (*Client, error) {
-	client, err := api.ClientFromEnvironment()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create ollama client: %w", err)
+	var client *api.Client
+	if cfg.Host == "" {
+		var err error
+		client, err = api.ClientFromEnvironment()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create ollama client: %w", err)
+		}
+	} else {
+		host, err := url.Parse(cfg.Host)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ollama host %q: %w", cfg.Host, err)
+		}
+		client = api.NewClient(host, http.DefaultClient)
 	}
 
 	return &Client{
@@ -29,25 +44,24 @@ func NewClient(cfg *config.OllamaConfig) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) GenerateResponse(ctx context.Context, prompt string) (string, error) {
+func (c *Client) GenerateResponse(ctx context.Context, request chat.Request) (chat.Response, error) {
 
 	shouldStream := false
+	model, sampling := chat.Resolve(request.Options, c.config.Model, c.config.Sampling)
 
 	req := &api.GenerateRequest{
-		Model:  c.config.Model,
-		Prompt: prompt,
-		Stream: &shouldStream,
-		Options: map[string]interface{}{
-			"temperature": 0.7,
-			"top_p":       0.9,
-		},
+		Model:   model,
+		Prompt:  request.Last(),
+		Stream:  &shouldStream,
+		Format:  chat.ReplySchema,
+		Options: samplingOptions(sampling),
 	}
 
 	// Create context with timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
 	defer cancel()
 
-	c.logger.Debug(fmt.Sprintf("Generating response with model %s", c.config.Model))
+	c.logger.Debug(fmt.Sprintf("Generating response with model %s", model))
 
 	var response string
 
@@ -61,25 +75,115 @@ func (c *Client) GenerateResponse(ctx context.Context, prompt string) (string, e
 	err := c.client.Generate(timeoutCtx, req, f)
 	if err != nil {
 		c.logger.WithError(err).Error("Failed to generate response")
-		return "", fmt.Errorf("ollama generation failed: %w", err)
+		return chat.Response{}, fmt.Errorf("ollama generation failed: %w", statusError(err))
 	}
 
-	return response, nil
+	return chat.Response{Text: response, Model: "ollama/" + model}, nil
+}
+
+// Chat sends the conversation to the Ollama chat API, keeping the role of
+// every message
+func (c *Client) Chat(ctx context.Context, request chat.Request) (chat.Response, error) {
+	return c.chat(ctx, request, false, nil)
 }
 
-func (c *Client) IsModelAvailable(ctx context.Context) error {
+// ChatStream is Chat with the reply passed to onToken as it is generated
+func (c *Client) ChatStream(ctx context.Context, request chat.Request, onToken func(token string) error) (chat.Response, error) {
+	return c.chat(ctx, request, true, onToken)
+}
+
+func (c *Client) chat(ctx context.Context, request chat.Request, shouldStream bool, onToken func(token string) error) (chat.Response, error) {
+
+	messages := request.Messages
+	model, sampling := chat.Resolve(request.Options, c.config.Model, c.config.Sampling)
+
+	req := &api.ChatRequest{
+		Model:    model,
+		Messages: make([]api.Message, len(messages)),
+		Stream:   &shouldStream,
+		Format:   chat.ReplySchema,
+		Options:  samplingOptions(sampling),
+	}
+	for i, msg := range messages {
+		req.Messages[i] = api.Message{Role: msg.Role, Content: msg.Content}
+	}
+
+	// Create context with timeout
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	c.logger.Debug(fmt.Sprintf("Chatting with model %s (%d messages)", model, len(messages)))
+
+	var response strings.Builder
+	f := func(r api.ChatResponse) error {
+		response.WriteString(r.Message.Content)
+		if onToken != nil && r.Message.Content != "" {
+			return onToken(r.Message.Content)
+		}
+		return nil
+	}
+
+	err := c.client.Chat(timeoutCtx, req, f)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to chat")
+		return chat.Response{}, fmt.Errorf("ollama chat failed: %w", statusError(err))
+	}
+
+	return chat.Response{Text: response.String(), Model: "ollama/" + model}, nil
+}
+
+// ModelName names the model the client uses for requests with opts
+func (c *Client) ModelName(opts chat.Options) string {
+	model, _ := chat.Resolve(opts, c.config.Model, c.config.Sampling)
+	return "ollama/" + model
+}
+
+// statusError turns an error status from the Ollama API into a
+// chat.StatusError, so callers can tell server errors from others
+func statusError(err error) error {
+	var status api.StatusError
+	if errors.As(err, &status) {
+		return &chat.StatusError{StatusCode: status.StatusCode, Message: status.ErrorMessage}
+	}
+	return err
+}
+
+// samplingOptions turns sampling settings into Ollama model options
+func samplingOptions(sampling config.SamplingConfig) map[string]interface{} {
+	options := make(map[string]interface{})
+	if sampling.Temperature != nil {
+		options["temperature"] = *sampling.Temperature
+	}
+	if sampling.TopP != nil {
+		options["top_p"] = *sampling.TopP
+	}
+	if sampling.NumCtx > 0 {
+		options["num_ctx"] = sampling.NumCtx
+	}
+	if sampling.Seed != nil {
+		options["seed"] = *sampling.Seed
+	}
+	if len(sampling.Stop) > 0 {
+		options["stop"] = sampling.Stop
+	}
+	return options
+}
+
+func (c *Client) IsModelAvailable(ctx context.Context, opts chat.Options) error {
+	want, _ := chat.Resolve(opts, c.config.Model, c.config.Sampling)
+
 	models, err := c.client.List(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to list models: %w", err)
 	}
 
 	for _, model := range models.Models {
-		if model.Name == c.config.Model {
+		if model.Name == want {
 			return nil
 		}
 	}
 
-	return fmt.Errorf("model %s not found. Available models: %v", c.config.Model, getModelNames(models.Models))
+	return fmt.Errorf("model %s not found. Available models: %v", want, getModelNames(models.Models))
 }
 
 func getModelNames(models []api.ListModelResponse) []string {
@@ -2,14 +2,17 @@
 package openai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
 	"github.com/tahcohcat/gofigure-web/internal/logger"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -22,16 +25,27 @@ type Client struct {
 }
 
 type OpenAIRequest struct {
-	Model       string          `json:"model"`
-	Messages    []OpenAIMessage `json:"messages"`
-	Temperature float64         `json:"temperature,omitempty"`
-	MaxTokens   int             `json:"max_tokens,omitempty"`
-	Stream      bool            `json:"stream"`
+	Model          string          `json:"model"`
+	Messages       []OpenAIMessage `json:"messages"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	TopP           *float64        `json:"top_p,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stream         bool            `json:"stream"`
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 type ResponseFormat struct {
-	Type string `json:"type"`
+	Type       string      `json:"type"`
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// JSONSchema is the schema of a json_schema response format
+type JSONSchema struct {
+	Name   string          `json:"name"`
+	Strict bool            `json:"strict"`
+	Schema json.RawMessage `json:"schema"`
 }
 
 type OpenAIMessage struct {
@@ -64,6 +78,20 @@ type OpenAIResponse struct {
 	} `json:"error,omitempty"`
 }
 
+// OpenAIStreamChunk is one server-sent event of a streamed chat completion
+type OpenAIStreamChunk struct {
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
 type ModelsResponse struct {
 	Object string `json:"object"`
 	Data   []struct {
@@ -95,53 +123,134 @@ func NewClient(cfg *config.OpenAIConfig) (*Client, error) {
 	}, nil
 }
 
-func (c *Client) GenerateResponse(ctx context.Context, prompt string) (string, error) {
-	// Parse the prompt - assuming it's JSON serialized conversation
-	var messages []struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
+// GenerateResponse sends the prompt as a single user message
+func (c *Client) GenerateResponse(ctx context.Context, request chat.Request) (chat.Response, error) {
+	return c.Chat(ctx, request)
+}
+
+// Chat sends the conversation as the Messages array of a chat completion
+func (c *Client) Chat(ctx context.Context, request chat.Request) (chat.Response, error) {
+	resp, err := c.postChat(ctx, request, false)
+	if err != nil {
+		return chat.Response{}, err
 	}
+	defer resp.Body.Close()
 
-	if err := json.Unmarshal([]byte(prompt), &messages); err != nil {
-		// If it's not JSON, treat as a simple prompt
-		messages = []struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		}{
-			{Role: "user", Content: prompt},
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return chat.Response{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var openaiResp OpenAIResponse
+	if err := json.Unmarshal(body, &openaiResp); err != nil {
+		return chat.Response{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if openaiResp.Error != nil {
+		return chat.Response{}, fmt.Errorf("openai API error: %s", openaiResp.Error.Message)
+	}
+
+	if len(openaiResp.Choices) == 0 {
+		return chat.Response{}, fmt.Errorf("no choices in OpenAI response")
+	}
+
+	response := openaiResp.Choices[0].Message.Content
+	c.logger.Debug(fmt.Sprintf("Generated response: %d tokens used", openaiResp.Usage.TotalTokens))
+
+	return chat.Response{Text: response, Model: c.ModelName(request.Options)}, nil
+}
+
+// ChatStream is Chat with stream: true. The reply arrives as server-sent
+// events, each carrying the next piece of the message.
+func (c *Client) ChatStream(ctx context.Context, request chat.Request, onToken func(token string) error) (chat.Response, error) {
+	resp, err := c.postChat(ctx, request, true)
+	if err != nil {
+		return chat.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var response strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk OpenAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return chat.Response{}, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return chat.Response{}, fmt.Errorf("openai API error: %s", chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		token := chunk.Choices[0].Delta.Content
+		response.WriteString(token)
+		if err := onToken(token); err != nil {
+			return chat.Response{}, err
 		}
 	}
 
-	// Convert to OpenAI format
-	var openaiMessages []OpenAIMessage
-	for _, msg := range messages {
-		openaiMessages = append(openaiMessages, OpenAIMessage{
+	if err := scanner.Err(); err != nil {
+		return chat.Response{}, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	return chat.Response{Text: response.String(), Model: c.ModelName(request.Options)}, nil
+}
+
+// postChat sends a chat completion request and checks the status of the
+// response. The caller closes the response body.
+func (c *Client) postChat(ctx context.Context, request chat.Request, stream bool) (*http.Response, error) {
+	openaiMessages := make([]OpenAIMessage, len(request.Messages))
+	for i, msg := range request.Messages {
+		openaiMessages[i] = OpenAIMessage{
 			Role:    msg.Role,
 			Content: msg.Content,
-		})
+		}
 	}
 
+	// num_ctx has no OpenAI equivalent; the context window is fixed by the model
+	model, sampling := chat.Resolve(request.Options, c.config.Model, c.config.Sampling)
+
 	req := OpenAIRequest{
-		Model:       c.config.Model,
+		Model:       model,
 		Messages:    openaiMessages,
-		Temperature: 0.7,
+		Temperature: sampling.Temperature,
+		TopP:        sampling.TopP,
+		Seed:        sampling.Seed,
+		Stop:        sampling.Stop,
 		MaxTokens:   c.config.MaxTokens,
-		Stream:      false,
+		Stream:      stream,
 		ResponseFormat: &ResponseFormat{
 			Type: "json_object",
 		},
 	}
+	if c.config.JSONSchema {
+		req.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: &JSONSchema{Name: "character_reply", Strict: true, Schema: chat.ReplySchema},
+		}
+	}
 
-	c.logger.Debug(fmt.Sprintf("Generating response with OpenAI model %s", c.config.Model))
+	c.logger.Debug(fmt.Sprintf("Generating response with OpenAI model %s", model))
 
 	requestBody, err := json.Marshal(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -150,40 +259,28 @@ func (c *Client) GenerateResponse(ctx context.Context, prompt string) (string, e
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		c.logger.WithError(err).Error("Failed to make OpenAI request")
-		return "", fmt.Errorf("openai request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("openai request failed: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
 		c.logger.Error(fmt.Sprintf("OpenAI API returned status %d: %s", resp.StatusCode, string(body)))
-		return "", fmt.Errorf("openai API error: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("openai API error: %w", &chat.StatusError{StatusCode: resp.StatusCode, Message: string(body)})
 	}
 
-	var openaiResp OpenAIResponse
-	if err := json.Unmarshal(body, &openaiResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	if openaiResp.Error != nil {
-		return "", fmt.Errorf("openai API error: %s", openaiResp.Error.Message)
-	}
-
-	if len(openaiResp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in OpenAI response")
-	}
-
-	response := openaiResp.Choices[0].Message.Content
-	c.logger.Debug(fmt.Sprintf("Generated response: %d tokens used", openaiResp.Usage.TotalTokens))
+	return resp, nil
+}
 
-	return response, nil
+// ModelName names the model the client uses for requests with opts
+func (c *Client) ModelName(opts chat.Options) string {
+	model, _ := chat.Resolve(opts, c.config.Model, c.config.Sampling)
+	return "openai/" + model
 }
 
-func (c *Client) IsModelAvailable(ctx context.Context) error {
+func (c *Client) IsModelAvailable(ctx context.Context, opts chat.Options) error {
+	want, _ := chat.Resolve(opts, c.config.Model, c.config.Sampling)
+
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -212,9 +309,9 @@ func (c *Client) IsModelAvailable(ctx context.Context) error {
 		return fmt.Errorf("failed to unmarshal models response: %w", err)
 	}
 
-	// Check if the configured model is available
+	// Check if the model asked for is available
 	for _, model := range modelsResp.Data {
-		if model.ID == c.config.Model {
+		if model.ID == want {
 			return nil
 		}
 	}
@@ -225,5 +322,5 @@ func (c *Client) IsModelAvailable(ctx context.Context) error {
 		availableModels = append(availableModels, model.ID)
 	}
 
-	return fmt.Errorf("model %s not found. Available models: %v", c.config.Model, availableModels)
+	return fmt.Errorf("model %s not found. Available models: %v", want, availableModels)
 }
@@ -0,0 +1,107 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Recording is one response recorded on a cassette
+type Recording struct {
+	Kind       string    `json:"kind"`   // generate or chat
+	Model      string    `json:"model"`  // the provider/model that answered
+	Prompt     string    `json:"prompt"` // the last message sent, to tell recordings apart
+	Response   string    `json:"response"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Cassette is a JSON file of recorded responses, keyed by the Key of the
+// request they answer
+type Cassette struct {
+	path string
+
+	mu         sync.RWMutex
+	recordings map[string]Recording
+}
+
+type cassetteFile struct {
+	Recordings map[string]Recording `json:"recordings"`
+}
+
+// LoadCassette reads the cassette at path. A missing file is an empty
+// cassette when create is set, and an error otherwise.
+func LoadCassette(path string, create bool) (*Cassette, error) {
+	c := &Cassette{path: path, recordings: make(map[string]Recording)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	for key, recording := range file.Recordings {
+		c.recordings[key] = recording
+	}
+
+	return c, nil
+}
+
+// Get returns the recording for key
+func (c *Cassette) Get(key string) (Recording, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	recording, ok := c.recordings[key]
+	return recording, ok
+}
+
+// Put records a response for key, replacing any earlier one, and saves the
+// cassette
+func (c *Cassette) Put(key string, recording Recording) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recording.RecordedAt = time.Now()
+	c.recordings[key] = recording
+	return c.save()
+}
+
+// Len returns the number of recordings
+func (c *Cassette) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.recordings)
+}
+
+// save writes the cassette to a temporary file first, so a crash never
+// leaves half a cassette behind
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(cassetteFile{Recordings: c.recordings}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+// Package replay is an LLM provider that needs no model. It records the
+// responses of a real provider to a cassette file, plays them back without
+// touching the network, or answers from a script of canned replies, so games
+// can run in CI and in offline demos.
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+	"github.com/tahcohcat/gofigure-web/internal/logger"
+)
+
+// Mode is how a Client answers
+type Mode string
+
+const (
+	ModeRecord   Mode = "record"   // pass requests on to a real provider and record its responses
+	ModePlayback Mode = "playback" // answer only from the cassette
+	ModeScript   Mode = "script"   // answer only from the script
+)
+
+// Kinds of request, as recorded on a cassette
+const (
+	kindGenerate = "generate"
+	kindChat     = "chat"
+)
+
+// Provider is the real provider a Client records. Every llm.LLM is one.
+type Provider interface {
+	GenerateResponse(ctx context.Context, req chat.Request) (chat.Response, error)
+	Chat(ctx context.Context, req chat.Request) (chat.Response, error)
+	IsModelAvailable(ctx context.Context, opts chat.Options) error
+}
+
+type streamer interface {
+	ChatStream(ctx context.Context, req chat.Request, onToken func(token string) error) (chat.Response, error)
+}
+
+type modelNamer interface {
+	ModelName(opts chat.Options) string
+}
+
+type Client struct {
+	config   *config.ReplayConfig
+	mode     Mode
+	provider Provider
+	cassette *Cassette
+	script   *Script
+	logger   *logger.Log
+}
+
+// NewClient creates a client in the mode cfg sets. provider is the provider
+// recorded in record mode, and is not used in the other modes.
+func NewClient(cfg *config.ReplayConfig, provider Provider) (*Client, error) {
+	c := &Client{
+		config:   cfg,
+		mode:     Mode(cfg.Mode),
+		provider: provider,
+		logger:   logger.New(),
+	}
+
+	var err error
+	switch c.mode {
+	case ModeRecord:
+		if provider == nil {
+			return nil, errors.New("replay record mode needs a provider to record")
+		}
+		c.cassette, err = LoadCassette(cfg.Cassette, true)
+	case ModePlayback:
+		if c.cassette, err = LoadCassette(cfg.Cassette, false); err == nil {
+			c.logger.Info(fmt.Sprintf("Playing back %d recorded responses from %s", c.cassette.Len(), cfg.Cassette))
+		}
+	case ModeScript:
+		c.script, err = LoadScript(cfg.Script)
+	default:
+		return nil, fmt.Errorf("unsupported replay mode: %s", cfg.Mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Client) GenerateResponse(ctx context.Context, req chat.Request) (chat.Response, error) {
+	return c.respond(ctx, kindGenerate, req, func() (chat.Response, error) {
+		return c.provider.GenerateResponse(ctx, req)
+	})
+}
+
+func (c *Client) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	return c.respond(ctx, kindChat, req, func() (chat.Response, error) {
+		return c.provider.Chat(ctx, req)
+	})
+}
+
+// ChatStream streams the reply of the recorded provider in record mode. In
+// the other modes the whole reply is passed to onToken at once.
+func (c *Client) ChatStream(ctx context.Context, req chat.Request, onToken func(token string) error) (chat.Response, error) {
+	if c.mode != ModeRecord {
+		reply, err := c.respond(ctx, kindChat, req, nil)
+		if err != nil {
+			return chat.Response{}, err
+		}
+		if err := onToken(reply.Text); err != nil {
+			return chat.Response{}, err
+		}
+		return reply, nil
+	}
+
+	return c.respond(ctx, kindChat, req, func() (chat.Response, error) {
+		if s, ok := c.provider.(streamer); ok {
+			return s.ChatStream(ctx, req, onToken)
+		}
+
+		reply, err := c.provider.Chat(ctx, req)
+		if err != nil {
+			return chat.Response{}, err
+		}
+		if err := onToken(reply.Text); err != nil {
+			return chat.Response{}, err
+		}
+		return reply, nil
+	})
+}
+
+// respond answers a request from the script or the cassette, or in record
+// mode with call, recording the response
+func (c *Client) respond(ctx context.Context, kind string, req chat.Request, call func() (chat.Response, error)) (chat.Response, error) {
+	if c.mode == ModeScript {
+		// Without a question to match the last message is the best guess,
+		// and no character's replies apply
+		q := chat.Question{Text: req.Last()}
+		if req.Question != nil {
+			q = *req.Question
+		}
+		reply, err := c.script.Reply(q)
+		if err != nil {
+			return chat.Response{}, err
+		}
+		return chat.Response{Text: reply, Model: c.ModelName(req.Options)}, nil
+	}
+
+	key := Key(kind, req)
+	if c.mode == ModePlayback {
+		recording, ok := c.cassette.Get(key)
+		if !ok {
+			return chat.Response{}, fmt.Errorf("no recorded response for %s request %s in %s", kind, key[:12], c.config.Cassette)
+		}
+		return chat.Response{Text: recording.Response, Model: c.ModelName(req.Options)}, nil
+	}
+
+	reply, err := call()
+	if err != nil {
+		return chat.Response{}, err
+	}
+
+	recording := Recording{
+		Kind:     kind,
+		Model:    reply.Model,
+		Prompt:   req.Last(),
+		Response: reply.Text,
+	}
+	if err := c.cassette.Put(key, recording); err != nil {
+		c.logger.WithError(err).Warn("Failed to record response")
+	}
+	return reply, nil
+}
+
+// Key identifies a request by a hash of everything that decides its
+// response: the kind of request, its model and sampling overrides and what
+// it asks. A question put to a character is identified by the mystery, the
+// character, the normalized question and what the answer must bring up, not
+// by its messages: they hold the character's stress, which is different in
+// every game. Any other request is identified by its messages.
+func Key(kind string, req chat.Request) string {
+	type asked struct {
+		Mystery   string   `json:"mystery"`
+		Character string   `json:"character"`
+		Question  string   `json:"question"`
+		Confess   []string `json:"confess,omitempty"`
+		Mention   []string `json:"mention,omitempty"`
+	}
+
+	var messages []chat.Message
+	var question *asked
+	if q := req.Question; q != nil && q.Text != "" {
+		question = &asked{Mystery: q.Mystery, Character: q.Character, Question: chat.Normalize(q.Text)}
+		if q.Persona != nil {
+			question.Confess, question.Mention = q.Persona.Confess, q.Persona.Mention
+		}
+	} else {
+		messages = req.Messages
+	}
+
+	data, _ := json.Marshal(struct {
+		Kind     string         `json:"kind"`
+		Options  chat.Options   `json:"options"`
+		Question *asked         `json:"question,omitempty"`
+		Messages []chat.Message `json:"messages,omitempty"`
+	}{kind, req.Options, question, messages})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsModelAvailable checks the recorded provider in record mode. The cassette
+// or script was loaded when the client was created, so the other modes are
+// always available.
+func (c *Client) IsModelAvailable(ctx context.Context, opts chat.Options) error {
+	if c.mode == ModeRecord {
+		return c.provider.IsModelAvailable(ctx, opts)
+	}
+	return nil
+}
+
+// ModelName is the model of the recorded provider in record mode, and
+// replay/playback or replay/script otherwise
+func (c *Client) ModelName(opts chat.Options) string {
+	if c.mode == ModeRecord {
+		if namer, ok := c.provider.(modelNamer); ok {
+			return namer.ModelName(opts)
+		}
+	}
+	return "replay/" + string(c.mode)
+}
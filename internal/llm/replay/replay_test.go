@@ -0,0 +1,156 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/tahcohcat/gofigure-web/config"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// fakeProvider answers with the last message it was sent, and counts the
+// requests that reach it
+type fakeProvider struct {
+	calls int
+}
+
+func (p *fakeProvider) GenerateResponse(ctx context.Context, req chat.Request) (chat.Response, error) {
+	p.calls++
+	return chat.Response{Text: "generated: " + req.Last(), Model: p.ModelName(req.Options)}, nil
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, req chat.Request) (chat.Response, error) {
+	p.calls++
+	return chat.Response{Text: "chat: " + req.Last(), Model: p.ModelName(req.Options)}, nil
+}
+
+func (p *fakeProvider) IsModelAvailable(ctx context.Context, opts chat.Options) error {
+	return nil
+}
+
+func (p *fakeProvider) ModelName(opts chat.Options) string {
+	return "fake/model"
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	cassette := filepath.Join(t.TempDir(), "cassettes", "game.json")
+	ctx := context.Background()
+	request := chat.Request{Messages: []chat.Message{
+		{Role: chat.RoleSystem, Content: "You are the captain."},
+		{Role: chat.RoleUser, Content: "Where were you?"},
+	}}
+	generate := chat.Prompt("Describe the deck", chat.Options{})
+	whoAreYou := []chat.Message{{Role: chat.RoleUser, Content: "Who are you?"}}
+
+	provider := &fakeProvider{}
+	recorder, err := NewClient(&config.ReplayConfig{Mode: string(ModeRecord), Cassette: cassette}, provider)
+	if err != nil {
+		t.Fatalf("NewClient record: %v", err)
+	}
+
+	recordedChat, err := recorder.Chat(ctx, request)
+	if err != nil {
+		t.Fatalf("record Chat: %v", err)
+	}
+	recordedGenerate, err := recorder.GenerateResponse(ctx, generate)
+	if err != nil {
+		t.Fatalf("record GenerateResponse: %v", err)
+	}
+	if _, err := recorder.Chat(ctx, chat.Request{Messages: whoAreYou, Options: chat.Options{Model: "other"}}); err != nil {
+		t.Fatalf("record Chat with options: %v", err)
+	}
+	if provider.calls != 3 {
+		t.Fatalf("provider got %d requests while recording, want 3", provider.calls)
+	}
+	if recordedChat.Model != "fake/model" {
+		t.Errorf("recorded a reply from %q, want fake/model", recordedChat.Model)
+	}
+
+	player, err := NewClient(&config.ReplayConfig{Mode: string(ModePlayback), Cassette: cassette}, nil)
+	if err != nil {
+		t.Fatalf("NewClient playback: %v", err)
+	}
+
+	played, err := player.Chat(ctx, request)
+	if err != nil {
+		t.Fatalf("playback Chat: %v", err)
+	}
+	if played.Text != recordedChat.Text {
+		t.Errorf("played back %q, recorded %q", played.Text, recordedChat.Text)
+	}
+	if played.Model != "replay/playback" {
+		t.Errorf("played back a reply from %q, want replay/playback", played.Model)
+	}
+
+	var streamed string
+	played, err = player.ChatStream(ctx, request, func(token string) error {
+		streamed += token
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("playback ChatStream: %v", err)
+	}
+	if played.Text != recordedChat.Text || streamed != recordedChat.Text {
+		t.Errorf("streamed %q and returned %q, recorded %q", streamed, played.Text, recordedChat.Text)
+	}
+
+	played, err = player.GenerateResponse(ctx, generate)
+	if err != nil {
+		t.Fatalf("playback GenerateResponse: %v", err)
+	}
+	if played.Text != recordedGenerate.Text {
+		t.Errorf("played back %q, recorded %q", played.Text, recordedGenerate.Text)
+	}
+
+	// The options are part of what was recorded
+	if _, err := player.Chat(ctx, chat.Request{Messages: whoAreYou}); err == nil {
+		t.Error("expected no recording for a request without the recorded options")
+	}
+	if _, err := player.Chat(ctx, chat.Prompt("Something new", chat.Options{})); err == nil {
+		t.Error("expected no recording for a request that wasn't recorded")
+	}
+
+	if provider.calls != 3 {
+		t.Errorf("provider got %d requests, want none during playback", provider.calls-3)
+	}
+}
+
+func TestNewClientErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		cfg      config.ReplayConfig
+		provider Provider
+	}{
+		{"record without a provider", config.ReplayConfig{Mode: string(ModeRecord), Cassette: filepath.Join(dir, "c.json")}, nil},
+		{"playback of a missing cassette", config.ReplayConfig{Mode: string(ModePlayback), Cassette: filepath.Join(dir, "missing.json")}, nil},
+		{"unknown mode", config.ReplayConfig{Mode: "rewind"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewClient(&tt.cfg, tt.provider); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestScriptModeWithoutQuestion(t *testing.T) {
+	path := writeScript(t, testScript)
+	client, err := NewClient(&config.ReplayConfig{Mode: string(ModeScript), Script: path}, nil)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	// Without a question only the script's default can answer
+	reply, err := client.Chat(context.Background(), chat.Prompt("Who has the key?", chat.Options{}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if want := `{"response":"I have nothing to say.","emotion":"neutral"}`; reply.Text != want {
+		t.Errorf("got %s, want %s", reply.Text, want)
+	}
+}
@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// Script holds canned replies per character, chosen by matching the
+// detective's question against patterns
+type Script struct {
+	Characters []ScriptedCharacter `mapstructure:"characters"`
+	Default    *ScriptedReply      `mapstructure:"default"` // for characters with no reply that matches
+}
+
+// ScriptedCharacter is the script of one character
+type ScriptedCharacter struct {
+	Name    string          `mapstructure:"name"`
+	Replies []ScriptedReply `mapstructure:"replies"` // tried in order
+	Default *ScriptedReply  `mapstructure:"default"` // when no reply matches
+}
+
+// ScriptedReply is a canned reply
+type ScriptedReply struct {
+	Pattern   string  `mapstructure:"pattern"` // regular expression matched against the question, ignoring case
+	Response  string  `mapstructure:"response"`
+	Emotion   string  `mapstructure:"emotion"`
+	Intensity float64 `mapstructure:"intensity"`
+
+	re *regexp.Regexp
+}
+
+// LoadScript reads the YAML script at path
+func LoadScript(path string) (*Script, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	var script Script
+	if err := v.Unmarshal(&script); err != nil {
+		return nil, fmt.Errorf("failed to parse script %s: %w", path, err)
+	}
+
+	for i := range script.Characters {
+		character := &script.Characters[i]
+		for j := range character.Replies {
+			if err := character.Replies[j].compile(); err != nil {
+				return nil, fmt.Errorf("invalid reply %d for %s: %w", j+1, character.Name, err)
+			}
+		}
+	}
+
+	return &script, nil
+}
+
+func (r *ScriptedReply) compile() error {
+	if strings.TrimSpace(r.Response) == "" {
+		return fmt.Errorf("response is missing")
+	}
+
+	re, err := regexp.Compile("(?i)" + r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	r.re = re
+	return nil
+}
+
+// Reply returns the first reply of the character q is put to whose pattern
+// matches the question, in the JSON format a model replies in. The
+// character's default reply, then the script's, is used when none matches.
+func (s *Script) Reply(q chat.Question) (string, error) {
+	reply := s.Default
+	for i := range s.Characters {
+		character := &s.Characters[i]
+		if !strings.EqualFold(character.Name, q.Character) {
+			continue
+		}
+
+		if character.Default != nil {
+			reply = character.Default
+		}
+		for j := range character.Replies {
+			if character.Replies[j].re.MatchString(q.Text) {
+				reply = &character.Replies[j]
+				break
+			}
+		}
+		break
+	}
+
+	if reply == nil {
+		return "", fmt.Errorf("the script has no reply for %s to %q", q.Character, q.Text)
+	}
+
+	emotion := reply.Emotion
+	if emotion == "" {
+		emotion = "neutral"
+	}
+	data, err := json.Marshal(struct {
+		Response  string  `json:"response"`
+		Emotion   string  `json:"emotion"`
+		Intensity float64 `json:"intensity,omitempty"`
+	}{reply.Response, emotion, reply.Intensity})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,148 @@
+package replay
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+const testScript = `
+characters:
+  - name: "Captain Rodriguez"
+    replies:
+      - pattern: "key|lock"
+        response: "Only senior staff carry master keys."
+        emotion: "confident"
+        intensity: 0.6
+      - pattern: "freezer"
+        response: "The freezer is checked every night."
+    default:
+      response: "Ask me something specific."
+      emotion: "calm"
+  - name: "Isabella Rossi"
+    replies:
+      - pattern: "^where were you"
+        response: "In the ballroom, darling."
+        emotion: "happy"
+default:
+  response: "I have nothing to say."
+  emotion: "neutral"
+`
+
+// writeScript writes a script to a temporary file and returns its path
+func writeScript(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "script.yaml")
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestScriptReply(t *testing.T) {
+	script, err := LoadScript(writeScript(t, testScript))
+	if err != nil {
+		t.Fatalf("LoadScript: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		character string
+		question  string
+		response  string
+		emotion   string
+	}{
+		{"first pattern that matches", "Captain Rodriguez", "Who has a key to the freezer?", "Only senior staff carry master keys.", "confident"},
+		{"later pattern", "Captain Rodriguez", "Was the freezer checked?", "The freezer is checked every night.", "neutral"},
+		{"patterns ignore case", "Captain Rodriguez", "WHO HAS THE KEY?", "Only senior staff carry master keys.", "confident"},
+		{"character names ignore case", "captain rodriguez", "the lock?", "Only senior staff carry master keys.", "confident"},
+		{"character default", "Captain Rodriguez", "Nice weather?", "Ask me something specific.", "calm"},
+		{"script default without a character default", "Isabella Rossi", "Did you like the chef?", "I have nothing to say.", "neutral"},
+		{"anchored pattern", "Isabella Rossi", "Where were you at nine?", "In the ballroom, darling.", "happy"},
+		{"anchored pattern only at the start", "Isabella Rossi", "So where were you?", "I have nothing to say.", "neutral"},
+		{"character not in the script", "Chef Laurent", "Where were you?", "I have nothing to say.", "neutral"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := script.Reply(chat.Question{Character: tt.character, Text: tt.question})
+			if err != nil {
+				t.Fatalf("Reply: %v", err)
+			}
+
+			var got struct {
+				Response string `json:"response"`
+				Emotion  string `json:"emotion"`
+			}
+			if err := json.Unmarshal([]byte(reply), &got); err != nil {
+				t.Fatalf("reply %s is not JSON: %v", reply, err)
+			}
+			if got.Response != tt.response || got.Emotion != tt.emotion {
+				t.Errorf("got %q (%s), want %q (%s)", got.Response, got.Emotion, tt.response, tt.emotion)
+			}
+		})
+	}
+}
+
+func TestScriptReplyWithoutDefault(t *testing.T) {
+	script, err := LoadScript(writeScript(t, `
+characters:
+  - name: "Captain Rodriguez"
+    replies:
+      - pattern: "key"
+        response: "Only senior staff carry master keys."
+`))
+	if err != nil {
+		t.Fatalf("LoadScript: %v", err)
+	}
+
+	if _, err := script.Reply(chat.Question{Character: "Captain Rodriguez", Text: "Nice weather?"}); err == nil {
+		t.Error("expected an error when no reply matches and there is no default")
+	}
+}
+
+func TestLoadScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"invalid pattern", `
+characters:
+  - name: "Captain Rodriguez"
+    replies:
+      - pattern: "key|("
+        response: "Only senior staff carry master keys."
+`, `invalid reply 1 for Captain Rodriguez: invalid pattern "key|("`},
+		{"missing response", `
+characters:
+  - name: "Captain Rodriguez"
+    replies:
+      - pattern: "key"
+      - pattern: "lock"
+        response: "   "
+`, "invalid reply 1 for Captain Rodriguez: response is missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadScript(writeScript(t, tt.script))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScriptMissingFile(t *testing.T) {
+	if _, err := LoadScript(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing script")
+	}
+}
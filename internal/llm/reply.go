@@ -0,0 +1,204 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// MaxReplyLength is the longest response text a reply may have, in characters
+const MaxReplyLength = chat.MaxReplyLength
+
+// ParseReply decodes the JSON reply of a model and checks it with
+// ValidateReply. JSON wrapped in other text is accepted. A valid reply has
+// its emotion mapped onto the canonical set. The reply is returned whenever
+// it could be decoded, even if it is invalid.
+func ParseReply(raw string) (*CharacterReply, error) {
+	reply, err := decodeReply(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateReply(reply); err != nil {
+		return reply, err
+	}
+
+	normalizeEmotion(reply)
+	return reply, nil
+}
+
+func decodeReply(raw string) (*CharacterReply, error) {
+	var reply CharacterReply
+	err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &reply)
+	if err != nil {
+		// Models sometimes wrap the JSON in text or code fences
+		start := strings.Index(raw, "{")
+		end := strings.LastIndex(raw, "}")
+		if start == -1 || end < start || json.Unmarshal([]byte(raw[start:end+1]), &reply) != nil {
+			return nil, fmt.Errorf("reply is not a JSON object: %w", err)
+		}
+	}
+
+	return &reply, nil
+}
+
+// ValidateReply checks that a reply has a response of at most
+// MaxReplyLength characters and an emotion that maps onto one of the
+// canonical emotions
+func ValidateReply(reply *CharacterReply) error {
+	var problems []string
+
+	if strings.TrimSpace(reply.Response) == "" {
+		problems = append(problems, `"response" is missing`)
+	} else if n := utf8.RuneCountInString(reply.Response); n > MaxReplyLength {
+		problems = append(problems, fmt.Sprintf(`"response" is %d characters long, the limit is %d`, n, MaxReplyLength))
+	}
+
+	if reply.Emotion == "" {
+		problems = append(problems, `"emotion" is missing`)
+	} else if _, ok := emotion.Parse(reply.Emotion); !ok {
+		problems = append(problems, fmt.Sprintf(`"emotion" %q is not one of: %s`, reply.Emotion, strings.Join(emotion.Names(), ", ")))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// normalizeEmotion maps the emotion of reply onto the canonical set. The
+// intensity comes from the word the model chose when it didn't give one.
+func normalizeEmotion(reply *CharacterReply) {
+	reading, _ := emotion.Parse(reply.Emotion)
+	reply.Emotion = string(reading.Emotion)
+
+	switch {
+	case reply.Intensity <= 0:
+		reply.Intensity = reading.Intensity
+	case reply.Intensity > 1:
+		reply.Intensity = 1
+	}
+}
+
+// RepairPrompt asks the model to send again a reply that failed validation
+func RepairPrompt(problem error) string {
+	return fmt.Sprintf("Your last reply could not be used: %v.\n\n"+
+		"Reply again with only this JSON object and no other text: "+
+		"{\"response\": \"your response here\", \"emotion\": \"one of: %s\", \"intensity\": 0.5}. "+
+		"Keep the response under %d characters.",
+		problem, strings.Join(emotion.Names(), ", "), MaxReplyLength)
+}
+
+// FallbackReply salvages what it can from a reply that never passed
+// validation. It never passes on fragments of JSON, so Response is empty
+// when nothing could be salvaged.
+func FallbackReply(raw string) *CharacterReply {
+	reply, err := decodeReply(raw)
+	if err != nil {
+		reply = &CharacterReply{}
+		if !strings.ContainsAny(raw, "{}[]\"") {
+			reply.Response = strings.TrimSpace(raw)
+		}
+	}
+
+	reply.Response = truncateReply(strings.TrimSpace(reply.Response), MaxReplyLength)
+	normalizeEmotion(reply)
+	return reply
+}
+
+// truncateReply shortens text to at most limit characters, at the end of a
+// sentence if there is one
+func truncateReply(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndexAny(cut, ".!?"); i > len(cut)/2 {
+		return cut[:i+1]
+	}
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// ModelNamer is implemented by clients that can name the model they use
+type ModelNamer interface {
+	ModelName(opts Options) string
+}
+
+// ModelName names the model client uses for requests with opts, as
+// provider/model, or "unknown"
+func ModelName(client LLM, opts Options) string {
+	if namer, ok := client.(ModelNamer); ok {
+		return namer.ModelName(opts)
+	}
+	return "unknown"
+}
+
+// ReplyOutcome is how a reply turned out
+type ReplyOutcome int
+
+const (
+	ReplyValid    ReplyOutcome = iota // valid the first time
+	ReplyRepaired                     // valid after a repair prompt
+	ReplyFallback                     // never valid, salvaged by FallbackReply
+)
+
+// ReplyStats counts how the replies of one model turned out
+type ReplyStats struct {
+	Model     string `json:"model"`
+	Replies   int64  `json:"replies"`
+	Retries   int64  `json:"retries"`   // repair prompts sent
+	Repaired  int64  `json:"repaired"`  // replies that were valid after a repair prompt
+	Fallbacks int64  `json:"fallbacks"` // replies that were never valid
+}
+
+var replyStats = struct {
+	sync.Mutex
+	models map[string]*ReplyStats
+}{models: make(map[string]*ReplyStats)}
+
+// RecordReply counts the outcome of a reply from model
+func RecordReply(model string, outcome ReplyOutcome) {
+	replyStats.Lock()
+	defer replyStats.Unlock()
+
+	stats, ok := replyStats.models[model]
+	if !ok {
+		stats = &ReplyStats{Model: model}
+		replyStats.models[model] = stats
+	}
+
+	stats.Replies++
+	switch outcome {
+	case ReplyRepaired:
+		stats.Retries++
+		stats.Repaired++
+	case ReplyFallback:
+		stats.Retries++
+		stats.Fallbacks++
+	}
+}
+
+// ReplyStatsByModel returns the reply counts of every model used since the
+// server started, by model name
+func ReplyStatsByModel() []ReplyStats {
+	replyStats.Lock()
+	defer replyStats.Unlock()
+
+	all := make([]ReplyStats, 0, len(replyStats.models))
+	for _, stats := range replyStats.models {
+		all = append(all, *stats)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Model < all[j].Model })
+	return all
+}
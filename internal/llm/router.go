@@ -0,0 +1,331 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+	"github.com/tahcohcat/gofigure-web/internal/logger"
+)
+
+// Route is one provider a Router can send requests to
+type Route struct {
+	Name   string // the provider, such as "ollama"
+	Client LLM
+}
+
+// Router sends each request to the first of an ordered list of providers.
+// A provider that times out, can't be reached or answers with a server error
+// is passed over for the next one. After a number of such errors in a row
+// its circuit breaker opens and it is skipped until the cooldown has
+// passed, when a single request is let through to see whether it is back.
+// Within the deadline of a request each provider waits only for its share of
+// the time left, so a slow one doesn't use up the time of those after it.
+type Router struct {
+	routes   []*route
+	failures int           // errors in a row that open a breaker
+	cooldown time.Duration // how long an open breaker skips its provider
+	logger   *logger.Log
+}
+
+type route struct {
+	Route
+
+	mu        sync.Mutex
+	failures  int       // retryable errors in a row
+	openUntil time.Time // zero while the breaker is closed
+	trial     bool      // a request is testing whether the provider is back
+	answered  int64
+	failed    int64
+}
+
+// Breaker states, as reported by ProviderStatus
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// ProviderStatus is the state of one provider of a Router
+type ProviderStatus struct {
+	Name      string     `json:"name"`
+	Breaker   string     `json:"breaker"`              // closed, open or half-open
+	Failures  int        `json:"failures"`             // retryable errors in a row
+	OpenUntil *time.Time `json:"open_until,omitempty"` // when an open breaker lets a request through again
+	Answered  int64      `json:"answered"`             // requests it answered
+	Failed    int64      `json:"failed"`               // requests passed on to the next provider
+}
+
+// NewRouter creates a router over routes, tried in order. A breaker opens
+// after failures retryable errors in a row and stays open for cooldown.
+func NewRouter(routes []Route, failures int, cooldown time.Duration) *Router {
+	if failures < 1 {
+		failures = 1
+	}
+
+	r := &Router{failures: failures, cooldown: cooldown, logger: logger.New()}
+	for _, rt := range routes {
+		r.routes = append(r.routes, &route{Route: rt})
+	}
+	return r
+}
+
+// GenerateResponse generates a response with the first provider that can
+func (r *Router) GenerateResponse(ctx context.Context, req Request) (Response, error) {
+	return r.do(ctx, req.Options, nil, func(ctx context.Context, client LLM) (Response, error) {
+		return client.GenerateResponse(ctx, req)
+	})
+}
+
+// Chat generates the next assistant message with the first provider that can
+func (r *Router) Chat(ctx context.Context, req Request) (Response, error) {
+	return r.do(ctx, req.Options, nil, func(ctx context.Context, client LLM) (Response, error) {
+		return client.Chat(ctx, req)
+	})
+}
+
+// ChatStream streams the reply of the first provider that can. Once a
+// provider has passed on part of its reply, an error ends the request rather
+// than starting the reply again with the next provider.
+func (r *Router) ChatStream(ctx context.Context, req Request, onToken TokenFunc) (Response, error) {
+	var started atomic.Bool
+	return r.do(ctx, req.Options, started.Load, func(ctx context.Context, client LLM) (Response, error) {
+		return ChatStream(ctx, client, req, func(token string) error {
+			started.Store(true)
+			return onToken(token)
+		})
+	})
+}
+
+// IsModelAvailable succeeds if any of the providers has its model
+func (r *Router) IsModelAvailable(ctx context.Context, opts Options) error {
+	var problems []string
+	for _, rt := range r.routes {
+		err := rt.Client.IsModelAvailable(ctx, opts)
+		if err == nil {
+			return nil
+		}
+		problems = append(problems, fmt.Sprintf("%s: %v", rt.Name, err))
+	}
+	return fmt.Errorf("no LLM provider is available: %s", strings.Join(problems, "; "))
+}
+
+// ModelName names the model of the first provider whose breaker is closed
+func (r *Router) ModelName(opts Options) string {
+	now := time.Now()
+	for _, rt := range r.routes {
+		if rt.state(now) != BreakerOpen {
+			return ModelName(rt.Client, opts)
+		}
+	}
+	return ModelName(r.routes[0].Client, opts)
+}
+
+// Status reports the breaker of every provider, in the order they are tried
+func (r *Router) Status() []ProviderStatus {
+	now := time.Now()
+	statuses := make([]ProviderStatus, 0, len(r.routes))
+	for _, rt := range r.routes {
+		state := rt.state(now)
+
+		rt.mu.Lock()
+		status := ProviderStatus{
+			Name:     rt.Name,
+			Breaker:  state,
+			Failures: rt.failures,
+			Answered: rt.answered,
+			Failed:   rt.failed,
+		}
+		if state == BreakerOpen {
+			openUntil := rt.openUntil
+			status.OpenUntil = &openUntil
+		}
+		rt.mu.Unlock()
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// errSlowProvider ends an attempt that has used up its share of the deadline
+var errSlowProvider = errors.New("LLM provider took too long to answer")
+
+// do sends a request with call to each provider in turn until one answers or
+// fails with an error that isn't worth passing on. started, if not nil,
+// reports whether the caller has already seen part of a reply.
+func (r *Router) do(ctx context.Context, opts Options, started func() bool, call func(ctx context.Context, client LLM) (Response, error)) (Response, error) {
+	var failed []string
+	for i, rt := range r.routes {
+		if !rt.allow(time.Now()) {
+			continue
+		}
+
+		attemptCtx, stop := r.attempt(ctx, i, started)
+		reply, err := call(attemptCtx, rt.Client)
+		slow := errors.Is(context.Cause(attemptCtx), errSlowProvider)
+		stop()
+
+		if err == nil {
+			rt.succeeded(true)
+			if reply.Model == "" {
+				reply.Model = ModelName(rt.Client, opts)
+			}
+			if len(failed) > 0 {
+				r.logger.Info(fmt.Sprintf("LLM reply from %s after %s failed", reply.Model, strings.Join(failed, ", ")))
+			} else {
+				r.logger.Debug(fmt.Sprintf("LLM reply from %s", reply.Model))
+			}
+			return reply, nil
+		}
+
+		if ctx.Err() != nil {
+			rt.release()
+			return Response{}, err
+		}
+		if slow {
+			err = errSlowProvider
+		} else if !Retryable(err) {
+			// The provider answered, it just didn't like the request
+			rt.succeeded(false)
+			return Response{}, err
+		}
+
+		if rt.fail(r.failures, r.cooldown) {
+			r.logger.WithError(err).Warn(fmt.Sprintf("LLM provider %s keeps failing, skipping it for %s", rt.Name, r.cooldown))
+		} else {
+			r.logger.WithError(err).Warn(fmt.Sprintf("LLM provider %s failed", rt.Name))
+		}
+		if started != nil && started() {
+			return Response{}, err
+		}
+		failed = append(failed, fmt.Sprintf("%s (%v)", rt.Name, err))
+	}
+
+	if len(failed) == 0 {
+		return Response{}, errors.New("every LLM provider is failing, try again shortly")
+	}
+	return Response{}, fmt.Errorf("no LLM provider could answer: %s", strings.Join(failed, "; "))
+}
+
+// attempt returns the context for a request to the provider of route i. When
+// ctx has a deadline and there are providers left to fall back on, the
+// attempt gets an even share of the time left, so a provider that hangs
+// leaves time for the rest. The share only bounds the wait for a reply to
+// start: a stream that has started may run on to the deadline of ctx.
+func (r *Router) attempt(ctx context.Context, i int, started func() bool) (context.Context, func()) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}
+	}
+
+	now := time.Now()
+	tries := 1
+	for _, next := range r.routes[i+1:] {
+		if next.state(now) != BreakerOpen {
+			tries++
+		}
+	}
+	if tries == 1 {
+		return ctx, func() {}
+	}
+
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(deadline.Sub(now)/time.Duration(tries), func() {
+		if started == nil || !started() {
+			cancel(errSlowProvider)
+		}
+	})
+	return attemptCtx, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// Retryable reports whether err suggests the provider is down or overloaded
+// rather than that the request was wrong: a timeout, a failed connection, a
+// server error or a rate limit
+func Retryable(err error) bool {
+	var status *chat.StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= http.StatusInternalServerError || status.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (rt *route) state(now time.Time) string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	switch {
+	case rt.openUntil.IsZero():
+		return BreakerClosed
+	case now.Before(rt.openUntil) || rt.trial:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// allow reports whether a request may go to the provider, letting a single
+// trial request through once the cooldown of an open breaker has passed
+func (rt *route) allow(now time.Time) bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if rt.openUntil.IsZero() {
+		return true
+	}
+	if now.Before(rt.openUntil) || rt.trial {
+		return false
+	}
+	rt.trial = true
+	return true
+}
+
+// succeeded closes the breaker once the provider has responded, and counts
+// the request as answered if it was
+func (rt *route) succeeded(answered bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if answered {
+		rt.answered++
+	}
+	rt.failures = 0
+	rt.openUntil = time.Time{}
+	rt.trial = false
+}
+
+// fail counts a retryable error and reports whether it opened the breaker.
+// A failed trial opens it again straight away.
+func (rt *route) fail(limit int, cooldown time.Duration) (opened bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.failed++
+	rt.failures++
+	if rt.trial || rt.failures >= limit {
+		rt.openUntil = time.Now().Add(cooldown)
+		rt.trial = false
+		return true
+	}
+	return false
+}
+
+// release ends a trial that was cancelled before it showed anything
+func (rt *route) release() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.trial = false
+}
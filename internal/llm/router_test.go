@@ -0,0 +1,259 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tahcohcat/gofigure-web/internal/llm/chat"
+)
+
+// fakeLLM answers every request as its model, or the one the request asks
+// for, with reply or else a valid reply, or fails with err. With a gate it
+// waits until the gate is closed or the request is cancelled. A stream sends
+// tokens before it answers or fails.
+type fakeLLM struct {
+	name   string
+	reply  string
+	tokens []string
+
+	mu    sync.Mutex
+	err   error
+	gate  chan struct{}
+	calls int
+}
+
+func (f *fakeLLM) GenerateResponse(ctx context.Context, req Request) (Response, error) {
+	return f.Chat(ctx, req)
+}
+
+func (f *fakeLLM) Chat(ctx context.Context, req Request) (Response, error) {
+	return f.ChatStream(ctx, req, func(string) error { return nil })
+}
+
+func (f *fakeLLM) ChatStream(ctx context.Context, req Request, onToken TokenFunc) (Response, error) {
+	f.mu.Lock()
+	f.calls++
+	err, gate := f.err, f.gate
+	f.mu.Unlock()
+
+	for _, token := range f.tokens {
+		if err := onToken(token); err != nil {
+			return Response{}, err
+		}
+	}
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+	}
+	if err != nil {
+		return Response{}, err
+	}
+	reply := f.reply
+	if reply == "" {
+		reply = `{"response":"Yes.","emotion":"neutral"}`
+	}
+	return Response{Text: reply, Model: f.ModelName(req.Options)}, nil
+}
+
+func (f *fakeLLM) IsModelAvailable(ctx context.Context, opts Options) error {
+	return nil
+}
+
+func (f *fakeLLM) ModelName(opts Options) string {
+	if opts.Model != "" {
+		return f.name + "/" + opts.Model
+	}
+	return f.name + "/model"
+}
+
+func (f *fakeLLM) set(err error, gate chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err, f.gate = err, gate
+}
+
+func (f *fakeLLM) called() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+var errUnavailable = &chat.StatusError{StatusCode: http.StatusServiceUnavailable, Message: "overloaded"}
+
+func ask(t *testing.T, r *Router) Response {
+	t.Helper()
+	reply, err := r.Chat(context.Background(), chat.Prompt("Where were you?", Options{}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	return reply
+}
+
+func TestRouterFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		model   string // the model that answers, empty for an error
+		backups int    // requests that reach the backup
+	}{
+		{"primary answers", nil, "primary/model", 0},
+		{"server error", errUnavailable, "backup/model", 1},
+		{"timeout", context.DeadlineExceeded, "backup/model", 1},
+		{"bad request", &chat.StatusError{StatusCode: http.StatusBadRequest, Message: "no such model"}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, backup := &fakeLLM{name: "primary", err: tt.err}, &fakeLLM{name: "backup"}
+			r := NewRouter([]Route{{Name: "primary", Client: primary}, {Name: "backup", Client: backup}}, 3, time.Minute)
+
+			reply, err := r.Chat(context.Background(), chat.Prompt("Where were you?", Options{}))
+			if tt.model == "" {
+				if err == nil {
+					t.Errorf("expected an error, got a reply from %s", reply.Model)
+				}
+			} else if err != nil {
+				t.Errorf("Chat: %v", err)
+			} else if reply.Model != tt.model {
+				t.Errorf("answered by %s, want %s", reply.Model, tt.model)
+			}
+			if backup.called() != tt.backups {
+				t.Errorf("backup got %d requests, want %d", backup.called(), tt.backups)
+			}
+		})
+	}
+}
+
+func TestRouterBreaker(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	primary, backup := &fakeLLM{name: "primary", err: errUnavailable}, &fakeLLM{name: "backup"}
+	r := NewRouter([]Route{{Name: "primary", Client: primary}, {Name: "backup", Client: backup}}, 2, cooldown)
+
+	breaker := func() string { return r.Status()[0].Breaker }
+
+	ask(t, r)
+	if got := breaker(); got != BreakerClosed {
+		t.Fatalf("breaker is %s after one error, want closed", got)
+	}
+	ask(t, r)
+	if got := breaker(); got != BreakerOpen {
+		t.Fatalf("breaker is %s after two errors, want open", got)
+	}
+
+	// An open breaker skips its provider
+	if reply := ask(t, r); reply.Model != "backup/model" || primary.called() != 2 {
+		t.Fatalf("open breaker let a request through: %d requests, answered by %s", primary.called(), reply.Model)
+	}
+
+	time.Sleep(cooldown)
+	if got := breaker(); got != BreakerHalfOpen {
+		t.Fatalf("breaker is %s after the cooldown, want half-open", got)
+	}
+
+	// A failed trial opens the breaker again straight away
+	ask(t, r)
+	if got := breaker(); got != BreakerOpen || primary.called() != 3 {
+		t.Fatalf("breaker is %s after a failed trial with %d requests, want open after 3", got, primary.called())
+	}
+
+	// Only one trial goes through while the provider is tested
+	time.Sleep(cooldown)
+	gate := make(chan struct{})
+	primary.set(nil, gate)
+
+	trial := make(chan Response)
+	go func() {
+		reply, _ := r.Chat(context.Background(), chat.Prompt("Where were you?", Options{}))
+		trial <- reply
+	}()
+	for primary.called() != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	if reply := ask(t, r); reply.Model != "backup/model" {
+		t.Errorf("a second request went to the provider on trial")
+	}
+	if got := breaker(); got != BreakerOpen {
+		t.Errorf("breaker is %s during the trial, want open", got)
+	}
+
+	close(gate)
+	if reply := <-trial; reply.Model != "primary/model" {
+		t.Errorf("trial answered by %s, want primary/model", reply.Model)
+	}
+	if got := breaker(); got != BreakerClosed {
+		t.Errorf("breaker is %s after a successful trial, want closed", got)
+	}
+	if primary.called() != 4 {
+		t.Errorf("primary got %d requests, want 4", primary.called())
+	}
+}
+
+func TestRouterDeadlineShare(t *testing.T) {
+	const timeout = 400 * time.Millisecond
+	hang := make(chan struct{})
+	defer close(hang)
+
+	primary, backup := &fakeLLM{name: "primary", gate: hang}, &fakeLLM{name: "backup"}
+	r := NewRouter([]Route{{Name: "primary", Client: primary}, {Name: "backup", Client: backup}}, 3, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	reply, err := r.Chat(ctx, chat.Prompt("Where were you?", Options{}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if reply.Model != "backup/model" {
+		t.Errorf("answered by %s, want backup/model", reply.Model)
+	}
+
+	// The primary waits half the time, leaving the rest to the backup
+	if elapsed := time.Since(start); elapsed >= timeout*3/4 {
+		t.Errorf("took %s of a %s deadline", elapsed, timeout)
+	}
+	if failed := r.Status()[0].Failed; failed != 1 {
+		t.Errorf("primary failed %d requests, want 1", failed)
+	}
+}
+
+func TestRouterStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  []string
+		backups int
+	}{
+		{"fails before the reply starts", nil, 1},
+		{"fails after the reply started", []string{`{"response":"I was`}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &fakeLLM{name: "primary", tokens: tt.tokens, err: errUnavailable}
+			backup := &fakeLLM{name: "backup"}
+			r := NewRouter([]Route{{Name: "primary", Client: primary}, {Name: "backup", Client: backup}}, 3, time.Minute)
+
+			var streamed string
+			_, err := r.ChatStream(context.Background(), chat.Prompt("Where were you?", Options{}), func(token string) error {
+				streamed += token
+				return nil
+			})
+			if tt.backups == 0 && !errors.Is(err, errUnavailable) {
+				t.Errorf("got error %v, want the error of the stream", err)
+			}
+			if tt.backups > 0 && err != nil {
+				t.Errorf("ChatStream: %v", err)
+			}
+			if backup.called() != tt.backups {
+				t.Errorf("backup got %d requests, want %d (streamed %q)", backup.called(), tt.backups, streamed)
+			}
+		})
+	}
+}
@@ -55,6 +55,60 @@ type UserGameSession struct {
 	Solved         *bool      `json:"solved" db:"solved"`
 	TimeSpent      *int       `json:"time_spent" db:"time_spent"` // in seconds
 	QuestionsAsked *int       `json:"questions_asked" db:"questions_asked"`
+	Score          *int       `json:"score" db:"score"` // accusation score out of 100
+}
+
+// SessionClue records a clue discovered during a game session
+type SessionClue struct {
+	ID           int       `json:"id" db:"id"`
+	SessionID    string    `json:"session_id" db:"session_id"`
+	ClueID       string    `json:"clue_id" db:"clue_id"`
+	Source       string    `json:"source" db:"source"` // question, stress or search
+	Character    string    `json:"character" db:"character"`
+	Location     string    `json:"location" db:"location"`
+	GameTime     int       `json:"game_time" db:"game_time"` // in seconds
+	DiscoveredAt time.Time `json:"discovered_at" db:"discovered_at"`
+}
+
+// SessionAccusation records one accusation made during a game session
+type SessionAccusation struct {
+	ID        int       `json:"id" db:"id"`
+	SessionID string    `json:"session_id" db:"session_id"`
+	Attempt   int       `json:"attempt" db:"attempt"` // 1 for the first accusation of the session
+	Suspect   string    `json:"suspect" db:"suspect"`
+	Weapon    string    `json:"weapon" db:"weapon"`
+	Location  string    `json:"location" db:"location"`
+	Motive    string    `json:"motive" db:"motive"`
+	Solved    bool      `json:"solved" db:"solved"`
+	Score     int       `json:"score" db:"score"`
+	GameTime  int       `json:"game_time" db:"game_time"` // in seconds
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// GameSnapshot is the saved state of a game, so it can be resumed after a
+// restart or on another day
+type GameSnapshot struct {
+	ID             int       `json:"id" db:"id"`
+	SessionID      string    `json:"session_id" db:"session_id"`
+	UserID         int       `json:"user_id" db:"user_id"`
+	MysteryID      string    `json:"mystery_id" db:"mystery_id"`
+	RemainingTime  int       `json:"remaining_time" db:"remaining_time"` // in seconds
+	QuestionsAsked int       `json:"questions_asked" db:"questions_asked"`
+	State          string    `json:"-" db:"state"` // JSON encoded game state
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	Finished       bool      `json:"finished" db:"finished"`
+}
+
+// GameEvent is one entry in the append-only log of a game session
+type GameEvent struct {
+	ID        int       `json:"id" db:"id"`
+	SessionID string    `json:"session_id" db:"session_id"`
+	Seq       int       `json:"seq" db:"seq"` // 1 for the first event of the session
+	Type      string    `json:"type" db:"type"`
+	Character string    `json:"character" db:"character"`
+	Data      string    `json:"data" db:"data"`           // JSON encoded event details
+	GameTime  int       `json:"game_time" db:"game_time"` // in seconds
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
 // SetPassword hashes and sets the user's password
@@ -9,12 +9,18 @@ import (
 	"github.com/tahcohcat/gofigure-web/internal/models"
 )
 
+// MysteryCatalog reports how many mysteries are available to play
+type MysteryCatalog interface {
+	Count() int
+}
+
 type AchievementService struct {
-	db *database.DB
+	db        *database.DB
+	mysteries MysteryCatalog
 }
 
-func NewAchievementService(db *database.DB) *AchievementService {
-	return &AchievementService{db: db}
+func NewAchievementService(db *database.DB, mysteries MysteryCatalog) *AchievementService {
+	return &AchievementService{db: db, mysteries: mysteries}
 }
 
 // GetUserAchievements returns all achievements with user's progress
@@ -159,7 +165,7 @@ func (s *AchievementService) checkMysteryAchievements(userID int, data map[strin
 	}
 
 	// Mystery Maven (solve all available mysteries)
-	totalMysteries := 4 // Update this based on your available mysteries
+	totalMysteries := s.mysteries.Count()
 	if stats.GamesWon >= totalMysteries {
 		s.UpdateAchievementProgress(userID, "mystery-maven", stats.GamesWon)
 	}
@@ -170,6 +176,13 @@ func (s *AchievementService) checkMysteryAchievements(userID int, data map[strin
 		s.UpdateAchievementProgress(userID, "veteran", 1)
 	}
 
+	// Comeback King (solve a mystery after 3 wrong accusations)
+	if sessionID, ok := data["session_id"].(string); ok && data["correct"] == true {
+		if s.getWrongAccusations(sessionID) >= 3 {
+			s.UpdateAchievementProgress(userID, "comeback-king", 1)
+		}
+	}
+
 	// Sherlock Holmes (90% success rate with 20+ cases)
 	if stats.GamesPlayed >= 20 {
 		successRate := float64(stats.GamesWon) / float64(stats.GamesPlayed) * 100
@@ -256,6 +269,16 @@ func (s *AchievementService) getTotalQuestionsAsked(userID int) int {
 	return total
 }
 
+func (s *AchievementService) getWrongAccusations(sessionID string) int {
+	var wrong int
+	query := `SELECT COUNT(*) FROM session_accusations WHERE session_id = ? AND solved = false`
+	err := s.db.Get(&wrong, query, sessionID)
+	if err != nil {
+		return 0
+	}
+	return wrong
+}
+
 func (s *AchievementService) getDaysSinceFirstGame(userID int) int {
 	var firstGame time.Time
 	query := `SELECT MIN(started_at) FROM user_game_sessions WHERE user_id = ?`
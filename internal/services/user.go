@@ -207,17 +207,137 @@ func (s *UserService) CreateGameSession(userID int, mysteryID, sessionID string)
 	return err
 }
 
-// CompleteGameSession records the completion of a game session
-func (s *UserService) CompleteGameSession(sessionID string, solved bool, timeSpent, questionsAsked int) error {
+// GetGameSession returns the record of a game session
+func (s *UserService) GetGameSession(sessionID string) (*models.UserGameSession, error) {
+	var session models.UserGameSession
+	query := `SELECT * FROM user_game_sessions WHERE session_id = ?`
+
+	err := s.db.Get(&session, query, sessionID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("game session not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get game session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// CompleteGameSession records the completion of a game session. score is
+// the accusation score out of 100.
+func (s *UserService) CompleteGameSession(sessionID string, solved bool, timeSpent, questionsAsked, score int) error {
 	query := `
 		UPDATE user_game_sessions 
-		SET finished_at = ?, solved = ?, time_spent = ?, questions_asked = ?
+		SET finished_at = ?, solved = ?, time_spent = ?, questions_asked = ?, score = ?
 		WHERE session_id = ?
 	`
-	_, err := s.db.Exec(query, time.Now(), solved, timeSpent, questionsAsked, sessionID)
+	_, err := s.db.Exec(query, time.Now(), solved, timeSpent, questionsAsked, score, sessionID)
+	return err
+}
+
+// RecordClue records a clue discovered during a game session
+func (s *UserService) RecordClue(clue *models.SessionClue) error {
+	query := `
+		INSERT OR IGNORE INTO session_clues (session_id, clue_id, source, character, location, game_time, discovered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, clue.SessionID, clue.ClueID, clue.Source, clue.Character, clue.Location, clue.GameTime, time.Now())
+	return err
+}
+
+// RecordAccusation records an accusation made during a game session
+func (s *UserService) RecordAccusation(accusation *models.SessionAccusation) error {
+	query := `
+		INSERT INTO session_accusations (session_id, attempt, suspect, weapon, location, motive, solved, score, game_time, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, accusation.SessionID, accusation.Attempt, accusation.Suspect, accusation.Weapon,
+		accusation.Location, accusation.Motive, accusation.Solved, accusation.Score, accusation.GameTime, time.Now())
+	return err
+}
+
+// SaveGameSnapshot stores the latest state of a game session, replacing any
+// earlier snapshot of it
+func (s *UserService) SaveGameSnapshot(snapshot *models.GameSnapshot) error {
+	query := `
+		INSERT INTO game_snapshots (session_id, user_id, mystery_id, remaining_time, questions_asked, state, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (session_id) DO UPDATE SET
+			remaining_time = excluded.remaining_time,
+			questions_asked = excluded.questions_asked,
+			state = excluded.state,
+			updated_at = excluded.updated_at
+	`
+	_, err := s.db.Exec(query, snapshot.SessionID, snapshot.UserID, snapshot.MysteryID,
+		snapshot.RemainingTime, snapshot.QuestionsAsked, snapshot.State, time.Now())
 	return err
 }
 
+// GetGameSnapshot returns the saved state of a game session
+func (s *UserService) GetGameSnapshot(sessionID string) (*models.GameSnapshot, error) {
+	var snapshot models.GameSnapshot
+	query := `
+		SELECT s.*, g.finished_at IS NOT NULL AS finished
+		FROM game_snapshots s
+		JOIN user_game_sessions g ON g.session_id = s.session_id
+		WHERE s.session_id = ?
+	`
+
+	err := s.db.Get(&snapshot, query, sessionID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("game snapshot not found")
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get game snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// GetUnfinishedGames returns the saved games a user can resume, most
+// recently played first
+func (s *UserService) GetUnfinishedGames(userID int) ([]models.GameSnapshot, error) {
+	var snapshots []models.GameSnapshot
+	query := `
+		SELECT s.*, 0 AS finished
+		FROM game_snapshots s
+		JOIN user_game_sessions g ON g.session_id = s.session_id
+		WHERE s.user_id = ? AND g.finished_at IS NULL
+		ORDER BY s.updated_at DESC
+	`
+	if err := s.db.Select(&snapshots, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get unfinished games: %w", err)
+	}
+	return snapshots, nil
+}
+
+// AppendGameEvent adds an event to the end of a session's log and sets its
+// sequence number
+func (s *UserService) AppendGameEvent(event *models.GameEvent) error {
+	query := `
+		INSERT INTO game_events (session_id, seq, type, character, data, game_time, created_at)
+		SELECT ?, COALESCE(MAX(seq), 0) + 1, ?, ?, ?, ?, ?
+		FROM game_events WHERE session_id = ?
+		RETURNING seq
+	`
+	event.CreatedAt = time.Now()
+	row := s.db.QueryRow(query, event.SessionID, event.Type, event.Character, event.Data,
+		event.GameTime, event.CreatedAt, event.SessionID)
+	if err := row.Scan(&event.Seq); err != nil {
+		return fmt.Errorf("failed to append game event: %w", err)
+	}
+	return nil
+}
+
+// GetGameEvents returns the log of a game session in order, starting after
+// the event with sequence number after
+func (s *UserService) GetGameEvents(sessionID string, after int) ([]models.GameEvent, error) {
+	var events []models.GameEvent
+	query := `SELECT * FROM game_events WHERE session_id = ? AND seq > ? ORDER BY seq`
+	if err := s.db.Select(&events, query, sessionID, after); err != nil {
+		return nil, fmt.Errorf("failed to get game events: %w", err)
+	}
+	return events, nil
+}
+
 // initializeUserStats creates initial stats record for a new user
 func (s *UserService) initializeUserStats(userID int) error {
 	query := `
@@ -8,6 +8,7 @@ import (
 
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	tts "cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/tahcohcat/gofigure-web/internal/emotion"
 	"github.com/tahcohcat/gofigure-web/internal/logger"
 	"google.golang.org/api/option"
 )
@@ -133,41 +134,14 @@ func (g *WebGoogleTTS) Close() error {
 	return nil
 }
 
-// Helper functions for emotion-based voice modulation
-func (g *WebGoogleTTS) getSpeakingRateForEmotion(emotion string) float64 {
-	switch strings.ToLower(emotion) {
-	case "excited", "happy", "energetic":
-		return 1.15
-	case "angry", "frustrated":
-		return 1.10
-	case "nervous", "worried", "anxious":
-		return 1.20
-	case "sad", "melancholy", "depressed":
-		return 0.85
-	case "mysterious", "suspicious":
-		return 0.90
-	case "calm", "peaceful", "serene":
-		return 0.95
-	default:
-		return 1.0
-	}
+// Helper functions for emotion-based voice modulation. The emotion may be
+// any word the emotion package recognises, such as "very anxious".
+func (g *WebGoogleTTS) getSpeakingRateForEmotion(name string) float64 {
+	mood, _ := emotion.Parse(name)
+	return mood.Prosody().SpeakingRate
 }
 
-func (g *WebGoogleTTS) getPitchForEmotion(emotion string) float64 {
-	switch strings.ToLower(emotion) {
-	case "excited", "happy", "surprised":
-		return 2.0
-	case "angry", "frustrated":
-		return -2.0
-	case "nervous", "worried":
-		return 3.0
-	case "sad", "melancholy":
-		return -3.0
-	case "mysterious", "suspicious":
-		return -1.5
-	case "authoritative", "confident":
-		return -1.0
-	default:
-		return 0.0
-	}
+func (g *WebGoogleTTS) getPitchForEmotion(name string) float64 {
+	mood, _ := emotion.Parse(name)
+	return mood.Prosody().Pitch
 }
@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// lineIndex maps value paths such as "characters[2].sprite" to the line the
+// value starts on, so issues can point authors at the right place.
+type lineIndex struct {
+	data  []byte
+	paths map[string]int
+}
+
+func indexLines(data []byte) *lineIndex {
+	idx := &lineIndex{data: data, paths: make(map[string]int)}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	idx.walk(dec, "")
+
+	return idx
+}
+
+// walk consumes one JSON value from dec, recording the line of it and of
+// every value nested inside it
+func (idx *lineIndex) walk(dec *json.Decoder, path string) bool {
+	start := idx.skipSpace(int(dec.InputOffset()))
+	idx.paths[path] = idx.lineAt(start)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return false
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return true
+	}
+
+	switch delim {
+	case '{':
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return false
+			}
+			key, _ := keyTok.(string)
+			if !idx.walk(dec, joinPath(path, key)) {
+				return false
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			if !idx.walk(dec, fmt.Sprintf("%s[%d]", path, i)) {
+				return false
+			}
+		}
+	}
+
+	// closing delimiter
+	_, err = dec.Token()
+	return err == nil
+}
+
+// line returns the line of path, falling back to its closest known parent
+func (idx *lineIndex) line(path string) int {
+	for {
+		if line, ok := idx.paths[path]; ok {
+			return line
+		}
+		cut := strings.LastIndexAny(path, ".[")
+		if cut < 0 {
+			return idx.paths[""]
+		}
+		path = path[:cut]
+	}
+}
+
+// skipSpace advances past whitespace and the separators the decoder leaves
+// behind so the offset lands on the first byte of the next value
+func (idx *lineIndex) skipSpace(offset int) int {
+	for offset < len(idx.data) {
+		switch idx.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func (idx *lineIndex) lineAt(offset int) int {
+	if offset > len(idx.data) {
+		offset = len(idx.data)
+	}
+	return bytes.Count(idx.data[:offset], []byte("\n")) + 1
+}
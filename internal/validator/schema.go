@@ -0,0 +1,210 @@
+package validator
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed schema/mystery.schema.json
+var schemaFS embed.FS
+
+const schemaFile = "schema/mystery.schema.json"
+
+// Schema returns the published JSON Schema for mystery files
+func Schema() []byte {
+	data, _ := schemaFS.ReadFile(schemaFile)
+	return data
+}
+
+// schema evaluates the subset of JSON Schema used by mystery.schema.json:
+// type, required, properties, items, enum, pattern, minLength, minItems,
+// minimum, maximum and local $ref into $defs.
+type schema struct {
+	root     map[string]interface{}
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+func loadSchema() (*schema, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(Schema(), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse mystery schema: %w", err)
+	}
+
+	return &schema{root: root, patterns: make(map[string]*regexp.Regexp)}, nil
+}
+
+func (s *schema) validate(value interface{}) []Issue {
+	var issues []Issue
+	s.check(s.root, value, "", &issues)
+	return issues
+}
+
+func (s *schema) check(node map[string]interface{}, value interface{}, path string, issues *[]Issue) {
+	if ref, ok := node["$ref"].(string); ok {
+		resolved, err := s.resolve(ref)
+		if err != nil {
+			*issues = append(*issues, Issue{Path: path, Message: err.Error()})
+			return
+		}
+		node = resolved
+	}
+
+	fail := func(format string, args ...interface{}) {
+		*issues = append(*issues, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if want, ok := node["type"].(string); ok && !hasType(value, want) {
+		fail("expected %s, got %s", want, typeName(value))
+		return
+	}
+
+	if enum, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %v is not one of %v", value, enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		if min, ok := node["minLength"].(float64); ok && float64(len(v)) < min {
+			fail("must be at least %v characters long", min)
+		}
+		if pattern, ok := node["pattern"].(string); ok {
+			re, err := s.compile(pattern)
+			if err != nil {
+				fail("invalid schema pattern %q: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("%q does not match pattern %s", v, pattern)
+			}
+		}
+
+	case float64:
+		if min, ok := node["minimum"].(float64); ok && v < min {
+			fail("%v is below the minimum of %v", v, min)
+		}
+		if max, ok := node["maximum"].(float64); ok && v > max {
+			fail("%v is above the maximum of %v", v, max)
+		}
+
+	case []interface{}:
+		if min, ok := node["minItems"].(float64); ok && float64(len(v)) < min {
+			fail("needs at least %v items, has %d", min, len(v))
+		}
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				s.check(items, item, fmt.Sprintf("%s[%d]", path, i), issues)
+			}
+		}
+
+	case map[string]interface{}:
+		if required, ok := node["required"].([]interface{}); ok {
+			for _, key := range required {
+				name, _ := key.(string)
+				if _, present := v[name]; !present {
+					fail("missing required field %q", name)
+				}
+			}
+		}
+		if props, ok := node["properties"].(map[string]interface{}); ok {
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if prop, ok := props[key].(map[string]interface{}); ok {
+					s.check(prop, v[key], joinPath(path, key), issues)
+				}
+			}
+		}
+	}
+}
+
+func (s *schema) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+
+	var node interface{} = s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+		}
+		node = m[part]
+	}
+
+	resolved, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+	}
+	return resolved, nil
+}
+
+func (s *schema) compile(pattern string) (*regexp.Regexp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if re, ok := s.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+func hasType(value interface{}, want string) bool {
+	switch want {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == float64(int64(f))
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeName(value) == want
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,274 @@
+// Package validator checks mystery JSON files against the published schema
+// and the cross-reference rules the game relies on at play time.
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Issue is a single problem found in a mystery file
+type Issue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Path == "" {
+		return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Path, i.Message)
+}
+
+// Error wraps the issues of a mystery that failed validation
+type Error struct {
+	Issues []Issue
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = issue.String()
+	}
+	return fmt.Sprintf("%d validation issue(s):\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// googleVoice matches the voice names accepted by Google Cloud TTS,
+// e.g. en-GB-Wavenet-N or en-IN-Chirp3-HD-Zubenelgenubi
+var googleVoice = regexp.MustCompile(`^[a-z]{2,3}-[A-Z]{2}-(Standard|Wavenet|Neural2|News|Studio|Polyglot|Casual|Journey|Chirp-HD|Chirp3-HD)-[A-Za-z0-9]+$`)
+
+// Validator checks mysteries against the schema and cross-reference rules
+type Validator struct {
+	staticDir string
+	schema    *schema
+}
+
+// New creates a validator that resolves sprite paths ("static/...") against staticDir
+func New(staticDir string) (*Validator, error) {
+	s, err := loadSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Validator{staticDir: staticDir, schema: s}, nil
+}
+
+// ValidateFile reads and validates a single mystery file
+func (v *Validator) ValidateFile(path string) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return v.Validate(path, data), nil
+}
+
+// ValidateDir validates every .json file in dir, returning issues sorted by file and line
+func (v *Validator) ValidateDir(dir string) ([]Issue, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var issues []Issue
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		fileIssues, err := v.ValidateFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	return issues, nil
+}
+
+// Validate checks the contents of a mystery file. name is only used to label issues.
+func (v *Validator) Validate(name string, data []byte) []Issue {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		line := 1
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line = indexLines(data).lineAt(int(syntaxErr.Offset))
+		}
+		return []Issue{{File: name, Line: line, Message: "invalid JSON: " + err.Error()}}
+	}
+
+	issues := v.schema.validate(doc)
+
+	// Cross references need the fields they look at to have the right shape;
+	// if they don't, the schema issues above already say so
+	var m mystery
+	if err := json.Unmarshal(data, &m); err == nil {
+		issues = append(issues, v.crossReference(&m)...)
+	} else if len(issues) == 0 {
+		issues = append(issues, Issue{Message: "failed to decode mystery: " + err.Error()})
+	}
+
+	lines := indexLines(data)
+	for i := range issues {
+		issues[i].File = name
+		issues[i].Line = lines.line(issues[i].Path)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Line < issues[j].Line
+	})
+
+	return issues
+}
+
+// mystery is the part of a mystery file the cross-reference rules look at
+type mystery struct {
+	Killer      string `json:"killer"`
+	NarratorTTS []tts  `json:"narrator_tts"`
+	Credits     []struct {
+		ImagePath string `json:"image_path"`
+	} `json:"credits"`
+	Characters []struct {
+		Name    string   `json:"name"`
+		Sprite  string   `json:"sprite"`
+		Secrets []string `json:"secrets"`
+		Stress  struct {
+			Rules []struct {
+				RevealsSecret int    `json:"reveals_secret"`
+				Behaviour     string `json:"behaviour"`
+			} `json:"rules"`
+		} `json:"stress"`
+		TTS []tts `json:"tts"`
+	} `json:"characters"`
+	Rooms []struct {
+		Name string `json:"name"`
+	} `json:"rooms"`
+	Clues []struct {
+		ID      string `json:"id"`
+		Trigger struct {
+			Character   string   `json:"character"`
+			Topics      []string `json:"topics"`
+			StressAbove float64  `json:"stress_above"`
+			Location    string   `json:"location"`
+		} `json:"trigger"`
+	} `json:"clues"`
+}
+
+type tts struct {
+	Engine string `json:"engine"`
+	Model  string `json:"model"`
+}
+
+func (v *Validator) crossReference(m *mystery) []Issue {
+	var issues []Issue
+	fail := func(path, format string, args ...interface{}) {
+		issues = append(issues, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	credited := make(map[string]bool)
+	for _, credit := range m.Credits {
+		credited[credit.ImagePath] = true
+	}
+
+	names := make(map[string]int)
+	for i, char := range m.Characters {
+		path := fmt.Sprintf("characters[%d]", i)
+
+		if first, dup := names[char.Name]; dup {
+			fail(path+".name", "duplicate character name %q (also characters[%d])", char.Name, first)
+		} else {
+			names[char.Name] = i
+		}
+
+		// An empty sprite would resolve to the static directory itself
+		if char.Sprite == "" {
+			fail(path+".sprite", "%s has no sprite", char.Name)
+		} else {
+			spriteFile := filepath.Join(v.staticDir, strings.TrimPrefix(char.Sprite, "static/"))
+			if info, err := os.Stat(spriteFile); err != nil || info.IsDir() {
+				fail(path+".sprite", "sprite %q has no file at %s", char.Sprite, spriteFile)
+			}
+			if !credited[char.Sprite] {
+				fail(path+".sprite", "sprite %q has no matching credits entry", char.Sprite)
+			}
+		}
+
+		for j, rule := range char.Stress.Rules {
+			rulePath := fmt.Sprintf("%s.stress.rules[%d]", path, j)
+			if rule.RevealsSecret > len(char.Secrets) {
+				fail(rulePath+".reveals_secret", "secret #%d does not exist, %s has %d secret(s)", rule.RevealsSecret, char.Name, len(char.Secrets))
+			}
+			if rule.RevealsSecret == 0 && rule.Behaviour == "" {
+				fail(rulePath, "rule neither reveals a secret nor changes behaviour")
+			}
+		}
+
+		issues = append(issues, checkVoices(path+".tts", char.TTS)...)
+	}
+
+	if _, ok := names[m.Killer]; !ok {
+		fail("killer", "killer %q does not match any character name", m.Killer)
+	}
+
+	rooms := make(map[string]int)
+	for i, room := range m.Rooms {
+		key := strings.ToLower(room.Name)
+		if first, dup := rooms[key]; dup {
+			fail(fmt.Sprintf("rooms[%d].name", i), "duplicate room name %q (also rooms[%d])", room.Name, first)
+		} else {
+			rooms[key] = i
+		}
+	}
+
+	clueIDs := make(map[string]int)
+	for i, clue := range m.Clues {
+		path := fmt.Sprintf("clues[%d]", i)
+		trigger := clue.Trigger
+
+		if first, dup := clueIDs[clue.ID]; dup {
+			fail(path+".id", "duplicate clue id %q (also clues[%d])", clue.ID, first)
+		} else {
+			clueIDs[clue.ID] = i
+		}
+
+		asking := len(trigger.Topics) > 0 || trigger.StressAbove > 0
+		switch {
+		case !asking && trigger.Location == "":
+			fail(path+".trigger", "clue can never be discovered: needs topics, stress_above or location")
+		case asking && trigger.Character == "":
+			fail(path+".trigger", "topics and stress_above need a character")
+		}
+		if _, ok := names[trigger.Character]; trigger.Character != "" && !ok {
+			fail(path+".trigger.character", "character %q does not match any character name", trigger.Character)
+		}
+		if _, ok := rooms[strings.ToLower(trigger.Location)]; trigger.Location != "" && !ok {
+			fail(path+".trigger.location", "location %q does not match any room name", trigger.Location)
+		}
+	}
+
+	issues = append(issues, checkVoices("narrator_tts", m.NarratorTTS)...)
+
+	return issues
+}
+
+func checkVoices(path string, voices []tts) []Issue {
+	var issues []Issue
+	for i, voice := range voices {
+		if voice.Engine == "google" && !googleVoice.MatchString(voice.Model) {
+			issues = append(issues, Issue{
+				Path:    fmt.Sprintf("%s[%d].model", path, i),
+				Message: fmt.Sprintf("%q is not a Google TTS voice name", voice.Model),
+			})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,142 @@
+package validator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mysteriesDir holds the mysteries that ship with the game
+var mysteriesDir = filepath.Join("..", "..", "data", "mysteries")
+
+// newTestValidator returns a validator whose static directory has a file for
+// every sprite of the shipped mysteries
+func newTestValidator(t *testing.T) *Validator {
+	t.Helper()
+
+	staticDir := t.TempDir()
+	files, err := filepath.Glob(filepath.Join(mysteriesDir, "*.json"))
+	if err != nil {
+		t.Fatalf("failed to list mysteries: %v", err)
+	}
+	for _, file := range files {
+		var m mystery
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("failed to decode %s: %v", file, err)
+		}
+		for _, char := range m.Characters {
+			sprite := filepath.Join(staticDir, strings.TrimPrefix(char.Sprite, "static/"))
+			if err := os.MkdirAll(filepath.Dir(sprite), 0755); err != nil {
+				t.Fatalf("failed to create sprite directory: %v", err)
+			}
+			if err := os.WriteFile(sprite, []byte("png"), 0644); err != nil {
+				t.Fatalf("failed to write sprite: %v", err)
+			}
+		}
+	}
+
+	v, err := New(staticDir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return v
+}
+
+// blackwood returns the Blackwood mystery after edit has changed it
+func blackwood(t *testing.T, edit func(m map[string]interface{})) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(mysteriesDir, "blackwood.json"))
+	if err != nil {
+		t.Fatalf("failed to read mystery: %v", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("failed to decode mystery: %v", err)
+	}
+	edit(m)
+	if data, err = json.MarshalIndent(m, "", "  "); err != nil {
+		t.Fatalf("failed to encode mystery: %v", err)
+	}
+	return data
+}
+
+// character returns the i'th character of mystery m
+func character(m map[string]interface{}, i int) map[string]interface{} {
+	return m["characters"].([]interface{})[i].(map[string]interface{})
+}
+
+func TestValidateShippedMysteries(t *testing.T) {
+	issues, err := newTestValidator(t).ValidateDir(mysteriesDir)
+	if err != nil {
+		t.Fatalf("ValidateDir: %v", err)
+	}
+	for _, issue := range issues {
+		t.Error(issue)
+	}
+}
+
+func TestValidateCrossReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(m map[string]interface{})
+		path    string
+		message string
+	}{
+		{"missing sprite", func(m map[string]interface{}) {
+			character(m, 0)["sprite"] = "static/images/characters/nobody.png"
+		}, "characters[0].sprite", "has no file"},
+		{"sprite is a directory", func(m map[string]interface{}) {
+			character(m, 0)["sprite"] = "static/images/characters"
+		}, "characters[0].sprite", "has no file"},
+		{"empty sprite", func(m map[string]interface{}) {
+			character(m, 0)["sprite"] = ""
+		}, "characters[0].sprite", "has no sprite"},
+		{"uncredited sprite", func(m map[string]interface{}) {
+			m["credits"] = m["credits"].([]interface{})[1:]
+		}, "characters[0].sprite", "no matching credits entry"},
+		{"duplicate character", func(m map[string]interface{}) {
+			character(m, 1)["name"] = character(m, 0)["name"]
+		}, "characters[1].name", "duplicate character name"},
+		{"unknown killer", func(m map[string]interface{}) {
+			m["killer"] = "The Butler"
+		}, "killer", "does not match any character name"},
+		{"unknown clue location", func(m map[string]interface{}) {
+			clue := m["clues"].([]interface{})[0].(map[string]interface{})
+			clue["trigger"] = map[string]interface{}{"location": "Ballroom"}
+		}, "clues[0].trigger.location", "does not match any room name"},
+	}
+
+	v := newTestValidator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := v.Validate("blackwood.json", blackwood(t, tt.edit))
+
+			found := false
+			for _, issue := range issues {
+				if issue.Path == tt.path && strings.Contains(issue.Message, tt.message) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("no issue at %s saying %q, got %v", tt.path, tt.message, issues)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidJSON(t *testing.T) {
+	issues := newTestValidator(t).Validate("broken.json", []byte("{\n  \"title\": \"Broken\",\n  \"killer\": \n}"))
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1: %v", len(issues), issues)
+	}
+	if issues[0].Line != 4 || !strings.HasPrefix(issues[0].Message, "invalid JSON") {
+		t.Errorf("got %s, want invalid JSON on line 4", issues[0])
+	}
+}
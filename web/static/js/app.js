@@ -75,6 +75,44 @@ class MysteryGame {
         } catch (error) {
             console.error('Failed to load mysteries:', error);
         }
+
+        await this.loadSavedGames();
+    }
+
+    async loadSavedGames() {
+        try {
+            const response = await fetch('/api/v1/game/sessions');
+            if (!response.ok) return;
+
+            const data = await response.json();
+            this.displaySavedGames(data.sessions || []);
+        } catch (error) {
+            console.error('Failed to load saved games:', error);
+        }
+    }
+
+    displaySavedGames(games) {
+        const savedGames = document.getElementById('saved-games');
+        const savedGameList = document.getElementById('saved-game-list');
+        savedGameList.innerHTML = '';
+        savedGames.classList.toggle('hidden', games.length === 0);
+
+        games.forEach(saved => {
+            const minutes = Math.floor(saved.remaining_time / 60);
+            const savedCard = document.createElement('div');
+            savedCard.className = 'mystery-card';
+            savedCard.innerHTML = `
+                <div class="mystery-header">
+                    <h3>${saved.title}</h3>
+                    <div class="difficulty-badge difficulty-${saved.difficulty.toLowerCase()}">${saved.difficulty}</div>
+                </div>
+                <p>${minutes} minutes left · ${saved.questions_asked} questions asked · last played ${new Date(saved.updated_at).toLocaleString()}</p>
+                <button class="btn btn-primary" onclick="game.resumeMystery('${saved.session_id}')">
+                    Resume Investigation
+                </button>
+            `;
+            savedGameList.appendChild(savedCard);
+        });
     }
 
     displayMysteries(mysteries) {
@@ -110,6 +148,12 @@ class MysteryGame {
                 body: JSON.stringify({ mystery_id: mysteryId })
             });
 
+            if (response.status === 503) {
+                // The model is unavailable; the server says why
+                alert(await response.text());
+                this.showScreen('mystery-selection');
+                return;
+            }
             if (!response.ok) {
                 throw new Error(`HTTP error! status: ${response.status}`);
             }
@@ -125,12 +169,75 @@ class MysteryGame {
         }
     }
 
+    async resumeMystery(sessionId) {
+        this.showScreen('loading-screen');
+
+        try {
+            const response = await fetch(`/api/v1/game/${sessionId}/resume`, { method: 'POST' });
+            if (!response.ok) {
+                throw new Error(`HTTP error! status: ${response.status}`);
+            }
+
+            const data = await response.json();
+            this.currentSession = data.session_id;
+            this.setupGame(data);
+
+            // Skip the introduction and replay what has been said so far
+            document.getElementById('intro-section').classList.add('hidden');
+            document.getElementById('investigation-section').classList.remove('hidden');
+            this.displaySavedConversations(data.conversations || {});
+            (data.clues || []).forEach(clue => this.displayClue(clue));
+
+            document.getElementById('timer-toggle-btn').textContent =
+                data.timer_enabled ? '⏳ Timer On' : '⏸️ Timer Off';
+            this.updateTimerDisplay(data.remaining_time);
+            this.startTimer();
+        } catch (error) {
+            console.error('Failed to resume mystery:', error);
+            alert('Failed to resume the investigation. Please try again.');
+            this.resetGame();
+        }
+    }
+
+    displaySavedConversations(conversations) {
+        const conversationHistory = document.getElementById('conversation-history');
+
+        Object.entries(conversations).forEach(([character, lines]) => {
+            lines.forEach(line => {
+                const lineDiv = document.createElement('div');
+                if (line.role === 'detective') {
+                    lineDiv.className = 'message detective-message';
+                    lineDiv.innerHTML = `<strong>You (to ${character}):</strong> ${line.text}`;
+                } else {
+                    lineDiv.className = `message character-message ${line.emotion}`;
+                    lineDiv.innerHTML = `
+                        <strong>${character}:</strong> ${line.text}
+                        <div class="message-meta">Emotion: ${line.emotion}</div>
+                    `;
+                }
+                conversationHistory.appendChild(lineDiv);
+            });
+        });
+
+        conversationHistory.scrollTop = conversationHistory.scrollHeight;
+    }
+
+    displayClue(clue) {
+        const conversationHistory = document.getElementById('conversation-history');
+        const clueDiv = document.createElement('div');
+        clueDiv.className = 'message clue-message';
+        clueDiv.innerHTML = `<strong>🔎 New clue: ${clue.name}</strong> ${clue.description}`;
+        conversationHistory.appendChild(clueDiv);
+        conversationHistory.scrollTop = conversationHistory.scrollHeight;
+    }
+
     setupGame(data) {
         document.getElementById('mystery-title').textContent = data.title;
         document.getElementById('intro-text').textContent = data.intro;
 
         // Setup characters with stress tracking
         this.displayCharacters(data.characters);
+        this.displayRooms(data.rooms || []);
         this.characterStressLevels = {};
         data.characters.forEach(char => {
             this.characterStressLevels[char.name] = 0;
@@ -138,6 +245,7 @@ class MysteryGame {
 
         this.gameData = data;
         this.showScreen('game-screen');
+        this.loadCharacterStress();
     }
 
     displayCharacters(characters) {
@@ -151,6 +259,7 @@ class MysteryGame {
                 <img src="/${character.sprite}" alt="${character.name}" class="character-avatar">
                 <div class="character-info">
                     <h4>${character.name}</h4>
+                    <p>${character.blurb || ''}</p>
                     <div class="stress-indicator">
                         <div class="stress-bar">
                             <div class="stress-fill" id="stress-${character.name}" style="width: 0%"></div>
@@ -165,6 +274,60 @@ class MysteryGame {
         });
     }
 
+    displayRooms(rooms) {
+        const roomsList = document.getElementById('rooms-list');
+        roomsList.innerHTML = '';
+
+        rooms.forEach(room => {
+            const button = document.createElement('button');
+            button.className = 'btn btn-secondary btn-room';
+            button.textContent = `🔍 ${room.name}`;
+            button.title = `${room.description} (searching takes 5 minutes)`;
+            button.addEventListener('click', () => this.searchRoom(room.name, button));
+            roomsList.appendChild(button);
+        });
+    }
+
+    async searchRoom(location, button) {
+        button.disabled = true;
+
+        try {
+            const response = await fetch(`/api/v1/game/${this.currentSession}/search`, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json'
+                },
+                body: JSON.stringify({ location: location })
+            });
+
+            if (!response.ok) {
+                throw new Error(`HTTP error! status: ${response.status}`);
+            }
+
+            const data = await response.json();
+            const conversationHistory = document.getElementById('conversation-history');
+
+            const narrationDiv = document.createElement('div');
+            narrationDiv.className = 'message system';
+            narrationDiv.innerHTML = `<strong>${data.speaker}:</strong> ${data.narration}`;
+            conversationHistory.appendChild(narrationDiv);
+
+            conversationHistory.scrollTop = conversationHistory.scrollHeight;
+            data.clues.forEach(clue => this.displayClue(clue));
+
+            this.updateTimerDisplay(data.remaining_time);
+
+            if (this.ttsEnabled) {
+                await this.playTTS(data.narration, data.speaker, data.emotion);
+            }
+        } catch (error) {
+            console.error('Failed to search location:', error);
+            alert('Failed to search. Please try again.');
+        } finally {
+            button.disabled = false;
+        }
+    }
+
     selectCharacter(character) {
         // Remove previous selection
         document.querySelectorAll('.character-card').forEach(card => {
@@ -203,18 +366,28 @@ class MysteryGame {
         askBtn.disabled = true;
         askBtn.textContent = 'Thinking...';
 
-        try {
-            const currentStress = this.characterStressLevels[this.selectedCharacter.name] || 0;
+        // Show the answer as it streams in; it is replaced by the full
+        // answer once the character has finished
+        const conversationHistory = document.getElementById('conversation-history');
+        const pending = document.createElement('div');
+        pending.className = 'streaming-answer';
+        pending.innerHTML = `
+            <div class="message detective-message"><strong>You:</strong> ${question}</div>
+            <div class="message character-message"><strong>${this.selectedCharacter.name}:</strong> <span class="streaming-text"></span></div>
+        `;
+        const streamingText = pending.querySelector('.streaming-text');
+        conversationHistory.appendChild(pending);
+        conversationHistory.scrollTop = conversationHistory.scrollHeight;
 
-            const response = await fetch(`/api/v1/game/${this.currentSession}/ask`, {
+        try {
+            const response = await fetch(`/api/v1/game/${this.currentSession}/ask/stream`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
                 },
                 body: JSON.stringify({
                     character_name: this.selectedCharacter.name,
-                    question: question,
-                    current_stress: currentStress
+                    question: question
                 })
             });
 
@@ -222,7 +395,23 @@ class MysteryGame {
                 throw new Error(`HTTP error! status: ${response.status}`);
             }
 
-            const data = await response.json();
+            let data = null;
+            await this.readEventStream(response, (event, payload) => {
+                if (event === 'token') {
+                    streamingText.textContent += payload.text;
+                    conversationHistory.scrollTop = conversationHistory.scrollHeight;
+                } else if (event === 'done') {
+                    data = payload;
+                } else if (event === 'error') {
+                    throw new Error(payload.error);
+                }
+            });
+
+            if (!data) {
+                throw new Error('The answer stream ended early');
+            }
+
+            pending.remove();
             this.displayConversation(question, data);
             this.updateStress(data.character, data.stress_level, data.stress_state);
 
@@ -231,10 +420,11 @@ class MysteryGame {
 
             // Play TTS if enabled
             if (this.ttsEnabled) {
-                await this.playTTS(data.response, data.character, data.emotion);
+                await this.playTTS(data.response, data.character, data.emotion, data.intensity);
             }
 
         } catch (error) {
+            pending.remove();
             console.error('Failed to ask question:', error);
             alert('Failed to get response. Please try again.');
         } finally {
@@ -243,6 +433,34 @@ class MysteryGame {
         }
     }
 
+    // readEventStream reads server-sent events from a fetch response and
+    // passes each event name and its JSON data to onEvent
+    async readEventStream(response, onEvent) {
+        const reader = response.body.getReader();
+        const decoder = new TextDecoder();
+        let buffer = '';
+
+        while (true) {
+            const { value, done } = await reader.read();
+            if (done) break;
+
+            buffer += decoder.decode(value, { stream: true });
+            let boundary;
+            while ((boundary = buffer.indexOf('\n\n')) !== -1) {
+                const block = buffer.slice(0, boundary);
+                buffer = buffer.slice(boundary + 2);
+
+                let event = 'message';
+                let data = '';
+                block.split('\n').forEach(line => {
+                    if (line.startsWith('event: ')) event = line.slice(7);
+                    else if (line.startsWith('data: ')) data += line.slice(6);
+                });
+                onEvent(event, data ? JSON.parse(data) : {});
+            }
+        }
+    }
+
     displayConversation(question, response) {
         const conversationHistory = document.getElementById('conversation-history');
 
@@ -256,6 +474,7 @@ class MysteryGame {
         // Add response
         const responseDiv = document.createElement('div');
         responseDiv.className = `message character-message ${response.emotion}`;
+        responseDiv.dataset.intensity = response.intensity;
         responseDiv.innerHTML = `
             <strong>${response.character}:</strong> ${response.response}
             <div class="message-meta">
@@ -265,7 +484,23 @@ class MysteryGame {
 
         conversationHistory.appendChild(questionDiv);
         conversationHistory.appendChild(responseDiv);
+
         conversationHistory.scrollTop = conversationHistory.scrollHeight;
+        (response.clues || []).forEach(clue => this.displayClue(clue));
+    }
+
+    async loadCharacterStress() {
+        try {
+            const response = await fetch(`/api/v1/game/${this.currentSession}/characters`);
+            if (!response.ok) return;
+
+            const data = await response.json();
+            data.characters.forEach(char => {
+                this.updateStress(char.name, char.stress_level, char.stress_state);
+            });
+        } catch (error) {
+            console.error('Failed to load character stress:', error);
+        }
     }
 
     updateStress(characterName, stressLevel, stressState) {
@@ -286,7 +521,7 @@ class MysteryGame {
         }
     }
 
-    async playTTS(text, character, emotion) {
+    async playTTS(text, character, emotion, intensity) {
         try {
             const response = await fetch('/api/v1/tts/speak', {
                 method: 'POST',
@@ -297,6 +532,7 @@ class MysteryGame {
                     text: text,
                     character: character,
                     emotion: emotion,
+                    intensity: intensity,
                     session_id: this.currentSession
                 })
             });
@@ -326,21 +562,43 @@ class MysteryGame {
                 <img src="/${character.sprite}" alt="${character.name}">
                 <span>${character.name}</span>
             `;
-            button.onclick = () => this.makeAccusation(character.name);
+            button.onclick = () => {
+                charactersDiv.querySelectorAll('.btn-accusation').forEach(b => b.classList.remove('selected'));
+                button.classList.add('selected');
+                this.accusedSuspect = character.name;
+                document.getElementById('submit-accusation').disabled = false;
+            };
             charactersDiv.appendChild(button);
         });
 
+        const rooms = document.getElementById('accusation-rooms');
+        rooms.innerHTML = '';
+        (this.gameData.rooms || []).forEach(room => {
+            const option = document.createElement('option');
+            option.value = room.name;
+            rooms.appendChild(option);
+        });
+
+        this.accusedSuspect = null;
+        document.getElementById('submit-accusation').disabled = true;
         modal.classList.remove('hidden');
     }
 
-    async makeAccusation(suspect) {
+    async makeAccusation() {
+        const accusation = {
+            suspect: this.accusedSuspect,
+            weapon: document.getElementById('accusation-weapon').value.trim(),
+            location: document.getElementById('accusation-location').value.trim(),
+            motive: document.getElementById('accusation-motive').value.trim()
+        };
+
         try {
             const response = await fetch(`/api/v1/game/${this.currentSession}/accuse`, {
                 method: 'POST',
                 headers: {
                     'Content-Type': 'application/json'
                 },
-                body: JSON.stringify({ suspect: suspect })
+                body: JSON.stringify(accusation)
             });
 
             if (!response.ok) {
@@ -348,7 +606,13 @@ class MysteryGame {
             }
 
             const result = await response.json();
-            this.showResult(result);
+            if (result.game_over === false) {
+                // Wrong, but the case is still open
+                this.updateTimerDisplay(result.remaining_time);
+                alert(result.message);
+            } else {
+                this.showResult(result);
+            }
 
         } catch (error) {
             console.error('Failed to make accusation:', error);
@@ -363,15 +627,28 @@ class MysteryGame {
         const title = document.getElementById('result-title');
         const message = document.getElementById('result-message');
 
+        const mark = part => part.correct ? '✅' : '❌';
+        const breakdown = result.breakdown ? `
+            <strong>Your theory (${result.score}/100):</strong><br>
+            ${mark(result.breakdown.suspect)} Suspect: ${result.breakdown.suspect.matched || result.breakdown.suspect.guess}<br>
+            ${mark(result.breakdown.weapon)} Weapon: ${result.breakdown.weapon.guess || '-'}<br>
+            ${mark(result.breakdown.location)} Location: ${result.breakdown.location.guess || '-'}<br>
+            ${mark(result.breakdown.motive)} Motive: ${result.breakdown.motive.guess || '-'}<br><br>
+        ` : '';
+
         title.textContent = result.correct ? '🎉 Case Solved!' : '❌ Case Unsolved';
         message.innerHTML = `
             ${result.message}<br><br>
+            ${breakdown}
             <strong>Solution:</strong><br>
+            Victim: ${result.victim}<br>
             Killer: ${result.killer}<br>
             Weapon: ${result.weapon}<br>
-            Location: ${result.location}<br><br>
+            Location: ${result.location}<br>
+            Motive: ${result.motive}<br><br>
             Time spent: ${Math.floor(result.time_spent / 60)}m ${result.time_spent % 60}s<br>
-            Questions asked: ${result.questions}
+            Questions asked: ${result.questions}<br>
+            Clues found: ${(result.clues || []).length} of ${(result.clues || []).length + (result.missed || []).length}
         `;
 
         modal.classList.remove('hidden');
@@ -380,6 +657,67 @@ class MysteryGame {
         if (this.timerInterval) {
             clearInterval(this.timerInterval);
         }
+
+        this.loadDebrief();
+    }
+
+    showTimeUp() {
+        document.getElementById('result-title').textContent = '⏰ Time\'s Up!';
+        document.getElementById('result-message').innerHTML = 'The case goes unsolved...';
+        document.getElementById('result-modal').classList.remove('hidden');
+        this.loadDebrief();
+    }
+
+    async loadDebrief() {
+        const container = document.getElementById('debrief');
+        container.classList.add('hidden');
+
+        ['md', 'html', 'json'].forEach(format => {
+            document.getElementById(`transcript-${format}`).href =
+                `/api/v1/game/${this.currentSession}/transcript?format=${format}`;
+        });
+
+        try {
+            const response = await fetch(`/api/v1/game/${this.currentSession}/debrief`);
+            if (!response.ok) return;
+
+            this.displayDebrief(await response.json());
+        } catch (error) {
+            console.error('Failed to load debrief:', error);
+        }
+    }
+
+    displayDebrief(debrief) {
+        const container = document.getElementById('debrief');
+        const minutes = seconds => `${Math.floor(seconds / 60)}m ${seconds % 60}s`;
+
+        const suspects = debrief.suspects.map(suspect => {
+            const secrets = suspect.secrets.length === 0 ? '<li>No secrets</li>' :
+                suspect.secrets.map(secret => `<li>${secret.uncovered ? '🔓' : '🔒'} ${secret.text}</li>`).join('');
+            return `
+                <div class="debrief-suspect">
+                    <strong>${suspect.killer ? '🔪 ' : ''}${suspect.name}</strong>
+                    ${suspect.reliable ? '(reliable)' : '(unreliable)'}<br>
+                    ${suspect.questions} questions · ${minutes(suspect.time_spent)} · peak stress ${Math.round(suspect.peak_stress)}
+                    <ul>${secrets}</ul>
+                </div>
+            `;
+        }).join('');
+
+        const missed = debrief.clues.missed.length === 0 ? '' : `
+            <strong>Clues you missed:</strong>
+            <ul>${debrief.clues.missed.map(clue => `<li>${clue.name}: ${clue.description}</li>`).join('')}</ul>
+        `;
+
+        container.innerHTML = `
+            <h3>Debrief</h3>
+            Secrets uncovered: ${debrief.secrets.uncovered} of ${debrief.secrets.uncovered + debrief.secrets.missed}<br>
+            Clues found: ${debrief.clues.found.length} of ${debrief.clues.found.length + debrief.clues.missed.length}<br>
+            Time searching: ${minutes(debrief.time.searching)}<br><br>
+            ${suspects}
+            ${missed}
+        `;
+        container.classList.remove('hidden');
     }
 
     startTimer() {
@@ -392,7 +730,9 @@ class MysteryGame {
 
                     if (data.game_over) {
                         clearInterval(this.timerInterval);
-                        alert('⏰ Time\'s up! The case goes unsolved...');
+                        if (document.getElementById('result-modal').classList.contains('hidden')) {
+                            this.showTimeUp();
+                        }
                     }
                 }
             } catch (error) {
@@ -432,6 +772,8 @@ class MysteryGame {
         document.getElementById('accuse-btn').addEventListener('click', () => this.showAccusationModal());
 
         // Modal controls
+        document.getElementById('submit-accusation').addEventListener('click', () => this.makeAccusation());
+
         document.getElementById('cancel-accusation').addEventListener('click', () => {
             document.getElementById('accusation-modal').classList.add('hidden');
         });
@@ -544,6 +886,7 @@ class MysteryGame {
         document.getElementById('timer').style.color = '';
 
         this.showScreen('mystery-selection');
+        this.loadSavedGames();
     }
     showProfile() {
         profile.show();